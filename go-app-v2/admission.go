@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// admissionConfig mirrors the controller settings AdmissionCheck depends on.
+type admissionConfig struct {
+	allowSnippets bool
+}
+
+// AdmissionOption adjusts the controller settings AdmissionCheck assumes.
+type AdmissionOption func(*admissionConfig)
+
+// AllowSnippetAnnotations mirrors the ingress-nginx allow-snippet-annotations
+// setting. Without it AdmissionCheck rejects any *-snippet annotation, as the
+// controller does by default since v1.9.
+func AllowSnippetAnnotations(allow bool) AdmissionOption {
+	return func(c *admissionConfig) { c.allowSnippets = allow }
+}
+
+// nginxBoolAnnotations are nginx annotations whose value must be a boolean in
+// any form strconv.ParseBool accepts, as ingress-nginx parses them.
+var nginxBoolAnnotations = []string{
+	"nginx.ingress.kubernetes.io/ssl-redirect",
+	"nginx.ingress.kubernetes.io/force-ssl-redirect",
+	"nginx.ingress.kubernetes.io/use-regex",
+	"nginx.ingress.kubernetes.io/canary",
+	"nginx.ingress.kubernetes.io/enable-cors",
+	"nginx.ingress.kubernetes.io/hsts",
+	"nginx.ingress.kubernetes.io/hsts-include-subdomains",
+	"nginx.ingress.kubernetes.io/hsts-preload",
+	"nginx.ingress.kubernetes.io/enable-access-log",
+	"nginx.ingress.kubernetes.io/ssl-passthrough",
+}
+
+// nginxIntAnnotations are nginx annotations whose value must be an integer.
+var nginxIntAnnotations = []string{
+	"nginx.ingress.kubernetes.io/proxy-connect-timeout",
+	"nginx.ingress.kubernetes.io/proxy-read-timeout",
+	"nginx.ingress.kubernetes.io/proxy-send-timeout",
+	"nginx.ingress.kubernetes.io/hsts-max-age",
+	"nginx.ingress.kubernetes.io/limit-rps",
+	"nginx.ingress.kubernetes.io/limit-rpm",
+	"nginx.ingress.kubernetes.io/limit-connections",
+	"nginx.ingress.kubernetes.io/canary-weight",
+	"nginx.ingress.kubernetes.io/canary-weight-total",
+}
+
+// AdmissionCheck replicates common ingress-nginx admission webhook rejections
+// offline so they can be caught in CI: duplicate host/path pairs, paths that
+// don't compile as regexes when use-regex is on, snippet annotations unless
+// AllowSnippetAnnotations(true) is passed, and annotation values of the wrong
+// type. It returns every violation found.
+//
+// Regex paths are compiled with Go's RE2 engine, which is stricter than the
+// PCRE engine nginx uses: lookarounds such as (?!...) and backreferences such
+// as \1 are valid in nginx but reported here as violations. Review those by
+// hand rather than treating them as certain rejections.
+func AdmissionCheck(ingress *networkingv1.Ingress, opts ...AdmissionOption) []string {
+	var cfg admissionConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var violations []string
+
+	useRegex, _ := strconv.ParseBool(ingress.Annotations["nginx.ingress.kubernetes.io/use-regex"])
+	seen := make(map[string]bool)
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			key := rule.Host + path.Path
+			if seen[key] {
+				violations = append(violations, fmt.Sprintf("duplicate path %s for host %q", path.Path, rule.Host))
+			}
+			seen[key] = true
+
+			if useRegex {
+				if _, err := regexp.Compile(path.Path); err != nil {
+					violations = append(violations, fmt.Sprintf("path %s is not a valid regex: %v", path.Path, err))
+				}
+			}
+		}
+	}
+
+	if !cfg.allowSnippets {
+		for _, key := range snippetAnnotations(ingress) {
+			violations = append(violations, fmt.Sprintf("annotation %s is not allowed: snippet annotations are disabled", key))
+		}
+	}
+
+	for _, key := range nginxBoolAnnotations {
+		if v, ok := ingress.Annotations[key]; ok {
+			if _, err := strconv.ParseBool(v); err != nil {
+				violations = append(violations, fmt.Sprintf("annotation %s must be a boolean, got %q", key, v))
+			}
+		}
+	}
+	for _, key := range nginxIntAnnotations {
+		if v, ok := ingress.Annotations[key]; ok {
+			if _, err := strconv.Atoi(v); err != nil {
+				violations = append(violations, fmt.Sprintf("annotation %s must be an integer, got %q", key, v))
+			}
+		}
+	}
+
+	return violations
+}
+
+// CanonicalizeAnnotations rewrites known boolean annotations to "true" or
+// "false" and known integer annotations to bare integers. For booleans this is
+// cosmetic: ingress-nginx already accepts "True" or "1", but one spelling
+// keeps manifests consistent and easy to diff. Integers are trimmed and
+// reformatted, which also turns a value such as " 30" into one the controller
+// parses. It returns the keys it changed, in sorted order. Values
+// that can't be parsed are left as they are and reported together in the
+// error.
+func CanonicalizeAnnotations(ingress *networkingv1.Ingress) ([]string, error) {
+	var changed, invalid []string
+
+	for _, key := range nginxBoolAnnotations {
+		v, ok := ingress.Annotations[key]
+		if !ok {
+			continue
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s=%q is not a boolean", key, v))
+			continue
+		}
+		if canonical := strconv.FormatBool(b); canonical != v {
+			ingress.Annotations[key] = canonical
+			changed = append(changed, key)
+		}
+	}
+	for _, key := range nginxIntAnnotations {
+		v, ok := ingress.Annotations[key]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s=%q is not an integer", key, v))
+			continue
+		}
+		if canonical := strconv.Itoa(n); canonical != v {
+			ingress.Annotations[key] = canonical
+			changed = append(changed, key)
+		}
+	}
+
+	sort.Strings(changed)
+	if len(invalid) > 0 {
+		return changed, fmt.Errorf("invalid annotation values: %s", strings.Join(invalid, "; "))
+	}
+	return changed, nil
+}
+
+// snippetAnnotations returns the nginx *-snippet annotation keys set on an
+// ingress, in sorted order.
+func snippetAnnotations(ingress *networkingv1.Ingress) []string {
+	var keys []string
+	for key := range ingress.Annotations {
+		if strings.HasPrefix(key, "nginx.ingress.kubernetes.io/") && strings.HasSuffix(key, "-snippet") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
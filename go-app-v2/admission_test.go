@@ -0,0 +1,165 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func TestAdmissionCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		extraPath   string
+		opts        []AdmissionOption
+		want        []string
+	}{
+		{name: "clean"},
+		{name: "duplicate path", extraPath: "/", want: []string{"duplicate path /"}},
+		{
+			name:        "invalid regex",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/use-regex": "true"},
+			extraPath:   "/api/(v1",
+			want:        []string{"path /api/(v1 is not a valid regex"},
+		},
+		{
+			// RE2 rejects PCRE-only syntax that nginx would accept.
+			name:        "lookahead",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/use-regex": "true"},
+			extraPath:   "/api/(?!internal)",
+			want:        []string{"path /api/(?!internal) is not a valid regex"},
+		},
+		{
+			name:        "backreference",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/use-regex": "true"},
+			extraPath:   `/(a|b)/\1`,
+			want:        []string{`path /(a|b)/\1 is not a valid regex`},
+		},
+		{
+			name:        "invalid regex without use-regex",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/use-regex": "false"},
+			extraPath:   "/api/(v1",
+		},
+		{
+			name:        "snippet",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/configuration-snippet": "more_set_headers \"X-A: b\";"},
+			want:        []string{"configuration-snippet is not allowed"},
+		},
+		{
+			name:        "snippet allowed",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/server-snippet": "return 204;"},
+			opts:        []AdmissionOption{AllowSnippetAnnotations(true)},
+		},
+		{
+			name: "bool spellings",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect":       "True",
+				"nginx.ingress.kubernetes.io/force-ssl-redirect": "1",
+				"nginx.ingress.kubernetes.io/hsts":               "FALSE",
+			},
+		},
+		{
+			name:        "not a bool",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/ssl-redirect": "yes"},
+			want:        []string{"ssl-redirect must be a boolean"},
+		},
+		{
+			name:        "not an integer",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/proxy-read-timeout": "60s"},
+			want:        []string{"proxy-read-timeout must be an integer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			ingress := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+			for k, v := range tt.annotations {
+				ingress.Annotations[k] = v
+			}
+			if tt.extraPath != "" {
+				paths := &ingress.Spec.Rules[0].HTTP.Paths
+				extra := *(*paths)[0].DeepCopy()
+				extra.Path = tt.extraPath
+				implementationSpecific := networkingv1.PathTypeImplementationSpecific
+				extra.PathType = &implementationSpecific
+				*paths = append(*paths, extra)
+			}
+
+			got := AdmissionCheck(ingress, tt.opts...)
+			if len(got) != len(tt.want) {
+				t.Fatalf("AdmissionCheck() = %q, want %d violations", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("violation %d = %q, want it to mention %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCanonicalizeAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        map[string]string
+		wantChanged []string
+		wantErr     bool
+	}{
+		{
+			name: "mixed-case booleans",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect": "True",
+				"nginx.ingress.kubernetes.io/use-regex":    "1",
+				"nginx.ingress.kubernetes.io/hsts":         "false",
+			},
+			want: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect": "true",
+				"nginx.ingress.kubernetes.io/use-regex":    "true",
+				"nginx.ingress.kubernetes.io/hsts":         "false",
+			},
+			wantChanged: []string{"nginx.ingress.kubernetes.io/ssl-redirect", "nginx.ingress.kubernetes.io/use-regex"},
+		},
+		{
+			name:        "padded integer",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/proxy-read-timeout": " 030"},
+			want:        map[string]string{"nginx.ingress.kubernetes.io/proxy-read-timeout": "30"},
+			wantChanged: []string{"nginx.ingress.kubernetes.io/proxy-read-timeout"},
+		},
+		{
+			name: "invalid values left alone",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect":       "maybe",
+				"nginx.ingress.kubernetes.io/force-ssl-redirect": "TRUE",
+			},
+			want: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect":       "maybe",
+				"nginx.ingress.kubernetes.io/force-ssl-redirect": "true",
+			},
+			wantChanged: []string{"nginx.ingress.kubernetes.io/force-ssl-redirect"},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown annotations untouched",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/$1"},
+			want:        map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/$1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingress := annotatedIngress(tt.annotations)
+			changed, err := CanonicalizeAnnotations(ingress)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CanonicalizeAnnotations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(changed, tt.wantChanged) {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if !reflect.DeepEqual(ingress.Annotations, tt.want) {
+				t.Errorf("annotations = %v, want %v", ingress.Annotations, tt.want)
+			}
+		})
+	}
+}
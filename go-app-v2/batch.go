@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+)
+
+// BatchFailure records an ingress that ProvisionBatch could not create.
+type BatchFailure struct {
+	Name string
+	Err  error
+}
+
+// BatchResult reports the outcome of ProvisionBatch per ingress, identified
+// as "namespace/name". Warnings holds the IngressWarnings of each ingress that
+// had any, whatever its outcome.
+type BatchResult struct {
+	Succeeded []string
+	Failed    []BatchFailure
+	Skipped   []string
+	Warnings  map[string][]string
+}
+
+// ProvisionBatch creates each ingress in turn, retrying transient API errors
+// with backoff. Each ingress gets one event and metric for its final outcome,
+// not one per attempt. Ingresses that already exist are skipped, unless the
+// conflict follows a transient error and so most likely comes from an earlier
+// attempt that went through; those count as succeeded. Other failures are
+// recorded without stopping the batch. The returned error is only set
+// when ctx is cancelled, in which case the result covers the ingresses
+// processed so far.
+func (m *IngressManager) ProvisionBatch(ctx context.Context, ingresses []*networkingv1.Ingress) (*BatchResult, error) {
+	result := &BatchResult{}
+	for _, ingress := range ingresses {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		name := fmt.Sprintf("%s/%s", ingress.Namespace, ingress.Name)
+		if warnings := IngressWarnings(ingress); len(warnings) > 0 {
+			if result.Warnings == nil {
+				result.Warnings = make(map[string][]string)
+			}
+			result.Warnings[name] = warnings
+		}
+
+		created, err := m.createIngressWithRetry(ctx, ingress)
+		switch {
+		case err == nil:
+			result.Succeeded = append(result.Succeeded, name)
+		case apierrors.IsAlreadyExists(err):
+			result.Skipped = append(result.Skipped, name)
+		default:
+			result.Failed = append(result.Failed, BatchFailure{Name: name, Err: err})
+		}
+		m.recordBatchCreate(created, ingress, err)
+	}
+	return result, nil
+}
+
+// createIngressWithRetry creates ingress, retrying transient API errors. A
+// transient error such as a timeout doesn't mean the create failed, so an
+// AlreadyExists after one is taken to be our own earlier attempt and treated
+// as success; the returned Ingress is nil in that case.
+func (m *IngressManager) createIngressWithRetry(ctx context.Context, ingress *networkingv1.Ingress) (*networkingv1.Ingress, error) {
+	var (
+		created  *networkingv1.Ingress
+		start    time.Time
+		attempts int
+	)
+	err := retry.OnError(retry.DefaultBackoff, isTransientError, func() error {
+		attempts++
+		start = m.metrics.start()
+		var err error
+		created, err = m.clientset.NetworkingV1().Ingresses(ingress.Namespace).Create(ctx, ingress, metav1.CreateOptions{})
+		return err
+	})
+	// Only the final attempt's latency is observed, so a retried create
+	// counts once like any other.
+	m.metrics.observe("create", start)
+	if attempts > 1 && apierrors.IsAlreadyExists(err) {
+		return nil, nil
+	}
+	return created, err
+}
+
+// recordBatchCreate records the final outcome of one ProvisionBatch create as
+// a single event and metric, however many attempts it took.
+func (m *IngressManager) recordBatchCreate(created, ingress *networkingv1.Ingress, err error) {
+	if err == nil {
+		m.metrics.count("created")
+	}
+	var result runtime.Object = created
+	if created == nil {
+		result = ingressRef(ingress.Namespace, ingress.Name)
+	}
+	m.recordResult(result, ingress.Namespace, ingress.Name, err, ReasonCreated, ReasonCreateFailed, "create")
+}
+
+// isTransientError reports whether an API error is worth retrying.
+func isTransientError(err error) bool {
+	return apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestProvisionBatch(t *testing.T) {
+	builder := &IngressManager{}
+	web := builder.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	api := builder.BuildBasicIngress("api", "storefront", "api.orcapod.io", "/", "api-backend", 8080)
+	flaky := builder.BuildBasicIngress("flaky", "storefront", "flaky.orcapod.io", "/", "flaky", 8080)
+	denied := builder.BuildBasicIngress("denied", "storefront", "denied.orcapod.io", "/", "denied", 8080)
+	existing := builder.BuildBasicIngress("existing", "storefront", "old.orcapod.io", "/", "old", 8080)
+
+	clientset := fake.NewSimpleClientset(existing.DeepCopy())
+	flakyFailures := 2
+	clientset.PrependReactor("create", "ingresses", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		ing := action.(k8stesting.CreateAction).GetObject().(*networkingv1.Ingress)
+		switch ing.Name {
+		case "flaky":
+			if flakyFailures > 0 {
+				flakyFailures--
+				return true, nil, apierrors.NewServiceUnavailable("apiserver overloaded")
+			}
+		case "denied":
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}, ing.Name, errors.New("denied"))
+		}
+		return false, nil, nil
+	})
+	m := NewIngressManager(clientset)
+
+	result, err := m.ProvisionBatch(context.Background(), []*networkingv1.Ingress{web, flaky, denied, existing, api})
+	if err != nil {
+		t.Fatalf("ProvisionBatch() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{name: "succeeded", got: result.Succeeded, want: []string{"storefront/web", "storefront/flaky", "storefront/api"}},
+		{name: "skipped", got: result.Skipped, want: []string{"storefront/existing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+
+	t.Run("failed", func(t *testing.T) {
+		if len(result.Failed) != 1 || result.Failed[0].Name != "storefront/denied" {
+			t.Fatalf("Failed = %v, want only storefront/denied", result.Failed)
+		}
+		if !apierrors.IsForbidden(result.Failed[0].Err) {
+			t.Errorf("Failed[0].Err = %v, want Forbidden", result.Failed[0].Err)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		result, err := m.ProvisionBatch(ctx, []*networkingv1.Ingress{web})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("ProvisionBatch() error = %v, want %v", err, context.Canceled)
+		}
+		if len(result.Succeeded)+len(result.Failed)+len(result.Skipped) != 0 {
+			t.Errorf("cancelled batch processed ingresses: %+v", result)
+		}
+	})
+}
+
+func TestProvisionBatchWarnings(t *testing.T) {
+	builder := &IngressManager{}
+	clean := builder.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	catchAll := builder.BuildBasicIngress("api", "storefront", "api.orcapod.io", "/", "api-backend", 8080)
+	blank := *catchAll.Spec.Rules[0].DeepCopy()
+	blank.Host = ""
+	catchAll.Spec.Rules = append(catchAll.Spec.Rules, blank)
+
+	m := NewIngressManager(fake.NewSimpleClientset())
+	result, err := m.ProvisionBatch(context.Background(), []*networkingv1.Ingress{clean, catchAll})
+	if err != nil {
+		t.Fatalf("ProvisionBatch() error = %v", err)
+	}
+	if len(result.Succeeded) != 2 {
+		t.Errorf("Succeeded = %v, want both ingresses", result.Succeeded)
+	}
+	want := map[string][]string{"storefront/api": IngressWarnings(catchAll)}
+	if len(want["storefront/api"]) == 0 || !reflect.DeepEqual(result.Warnings, want) {
+		t.Errorf("Warnings = %v, want %v", result.Warnings, want)
+	}
+}
+
+func TestProvisionBatchRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    bool
+		react       func(tracker k8stesting.ObjectTracker, ing *networkingv1.Ingress, attempt int) error
+		wantOutcome string
+		wantEvent   string
+		wantCreated float64
+		wantCalls   int
+	}{
+		{
+			name: "transient then created",
+			react: func(_ k8stesting.ObjectTracker, _ *networkingv1.Ingress, attempt int) error {
+				if attempt <= 2 {
+					return apierrors.NewServiceUnavailable("apiserver overloaded")
+				}
+				return nil
+			},
+			wantOutcome: "succeeded",
+			wantEvent:   "Normal Created Ingress created by provisioner",
+			wantCreated: 1,
+			wantCalls:   3,
+		},
+		{
+			name: "timed out but committed",
+			react: func(tracker k8stesting.ObjectTracker, ing *networkingv1.Ingress, attempt int) error {
+				if attempt == 1 {
+					if err := tracker.Add(ing.DeepCopy()); err != nil {
+						return err
+					}
+					return apierrors.NewTimeoutError("request timed out", 1)
+				}
+				return nil
+			},
+			wantOutcome: "succeeded",
+			wantEvent:   "Normal Created Ingress created by provisioner",
+			wantCreated: 1,
+			wantCalls:   2,
+		},
+		{
+			name: "always unavailable",
+			react: func(k8stesting.ObjectTracker, *networkingv1.Ingress, int) error {
+				return apierrors.NewServiceUnavailable("apiserver overloaded")
+			},
+			wantOutcome: "failed",
+			wantEvent:   "Warning CreateFailed Failed to create ingress",
+			wantCalls:   4,
+		},
+		{
+			name:        "already exists",
+			existing:    true,
+			wantOutcome: "skipped",
+			wantEvent:   "Warning CreateFailed Failed to create ingress",
+			wantCalls:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingress := (&IngressManager{}).BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+			clientset := fake.NewSimpleClientset()
+			if tt.existing {
+				clientset = fake.NewSimpleClientset(ingress.DeepCopy())
+			}
+			calls := 0
+			clientset.PrependReactor("create", "ingresses", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				calls++
+				if tt.react == nil {
+					return false, nil, nil
+				}
+				ing := action.(k8stesting.CreateAction).GetObject().(*networkingv1.Ingress)
+				if err := tt.react(clientset.Tracker(), ing, calls); err != nil {
+					return true, nil, err
+				}
+				return false, nil, nil
+			})
+			m := NewIngressManager(clientset)
+			recorder := record.NewFakeRecorder(10)
+			m.SetEventRecorder(recorder)
+			reg := prometheus.NewRegistry()
+			m.MustRegister(reg)
+
+			result, err := m.ProvisionBatch(context.Background(), []*networkingv1.Ingress{ingress})
+			if err != nil {
+				t.Fatalf("ProvisionBatch() error = %v", err)
+			}
+			got := map[string]int{
+				"succeeded": len(result.Succeeded),
+				"failed":    len(result.Failed),
+				"skipped":   len(result.Skipped),
+			}
+			want := map[string]int{"succeeded": 0, "failed": 0, "skipped": 0}
+			want[tt.wantOutcome] = 1
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("outcomes = %v, want %v", got, want)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("create calls = %d, want %d", calls, tt.wantCalls)
+			}
+
+			events := drainEvents(recorder)
+			if len(events) != 1 || !strings.HasPrefix(events[0], tt.wantEvent) {
+				t.Errorf("events = %q, want one %q", events, tt.wantEvent)
+			}
+			if got := testutil.ToFloat64(m.metrics.ingresses.WithLabelValues("created")); got != tt.wantCreated {
+				t.Errorf("ingresses_total{operation=\"created\"} = %v, want %v", got, tt.wantCreated)
+			}
+			families, err := reg.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var samples uint64
+			for _, mf := range families {
+				if mf.GetName() == "ingress_provisioner_api_request_duration_seconds" {
+					samples = mf.GetMetric()[0].GetHistogram().GetSampleCount()
+				}
+			}
+			if samples != 1 {
+				t.Errorf("latency samples = %d, want 1", samples)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// DescribeCanaryBehavior explains in plain words how nginx routes traffic for
+// a canary Ingress. nginx evaluates canary rules in a fixed order: header
+// first, then cookie, then weight. So a header match always wins for matching
+// requests and the weight only applies to the rest.
+func DescribeCanaryBehavior(ingress *networkingv1.Ingress) string {
+	a := ingress.Annotations
+	if canary, _ := nginxBoolAnnotation(a, "nginx.ingress.kubernetes.io/canary"); !canary {
+		return "not a canary ingress"
+	}
+
+	var steps []string
+	if header := a["nginx.ingress.kubernetes.io/canary-by-header"]; header != "" {
+		switch {
+		case a["nginx.ingress.kubernetes.io/canary-by-header-value"] != "":
+			steps = append(steps, fmt.Sprintf("requests with header %s: %s go to the canary",
+				header, a["nginx.ingress.kubernetes.io/canary-by-header-value"]))
+		case a["nginx.ingress.kubernetes.io/canary-by-header-pattern"] != "":
+			steps = append(steps, fmt.Sprintf("requests with header %s matching %s go to the canary",
+				header, a["nginx.ingress.kubernetes.io/canary-by-header-pattern"]))
+		default:
+			steps = append(steps, fmt.Sprintf(`header %s="always" routes to the canary, "never" stays on stable`, header))
+		}
+	}
+	if cookie := a["nginx.ingress.kubernetes.io/canary-by-cookie"]; cookie != "" {
+		steps = append(steps, fmt.Sprintf(`cookie %s="always" routes to the canary, "never" stays on stable`, cookie))
+	}
+
+	weight := a["nginx.ingress.kubernetes.io/canary-weight"]
+	if weight == "" {
+		weight = "0"
+	}
+	total := a["nginx.ingress.kubernetes.io/canary-weight-total"]
+	if total == "" {
+		total = "100"
+	}
+	if len(steps) == 0 {
+		steps = append(steps, fmt.Sprintf("%s/%s of requests go to the canary", weight, total))
+	} else {
+		steps = append(steps, fmt.Sprintf("of the remaining requests, %s/%s go to the canary", weight, total))
+	}
+
+	return strings.Join(steps, "; ")
+}
+
+// canaryWarnings flags canary configuration that has no effect: an explicit
+// canary-weight of 0 alongside header routing means the weight is dead config.
+func canaryWarnings(ingress *networkingv1.Ingress) []string {
+	a := ingress.Annotations
+	if canary, _ := nginxBoolAnnotation(a, "nginx.ingress.kubernetes.io/canary"); !canary {
+		return nil
+	}
+	weight, hasWeight := a["nginx.ingress.kubernetes.io/canary-weight"]
+	if hasWeight && weight == "0" && a["nginx.ingress.kubernetes.io/canary-by-header"] != "" {
+		return []string{"canary-weight is 0 so only canary-by-header routes to the canary; the weight annotation has no effect"}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import "testing"
+
+func TestDescribeCanaryBehavior(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        string
+	}{
+		{name: "not a canary", want: "not a canary ingress"},
+		{
+			name: "weight only",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":        "true",
+				"nginx.ingress.kubernetes.io/canary-weight": "20",
+			},
+			want: "20/100 of requests go to the canary",
+		},
+		{
+			name: "canary spelled True",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":        "True",
+				"nginx.ingress.kubernetes.io/canary-weight": "20",
+			},
+			want: "20/100 of requests go to the canary",
+		},
+		{
+			name: "canary false",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":        "false",
+				"nginx.ingress.kubernetes.io/canary-weight": "20",
+			},
+			want: "not a canary ingress",
+		},
+		{
+			name: "header and weight",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":                 "true",
+				"nginx.ingress.kubernetes.io/canary-by-header":       "X-Canary",
+				"nginx.ingress.kubernetes.io/canary-by-header-value": "beta",
+				"nginx.ingress.kubernetes.io/canary-weight":          "10",
+				"nginx.ingress.kubernetes.io/canary-weight-total":    "1000",
+			},
+			want: "requests with header X-Canary: beta go to the canary; of the remaining requests, 10/1000 go to the canary",
+		},
+		{
+			name: "header without value",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":           "true",
+				"nginx.ingress.kubernetes.io/canary-by-header": "X-Canary",
+			},
+			want: `header X-Canary="always" routes to the canary, "never" stays on stable; of the remaining requests, 0/100 go to the canary`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DescribeCanaryBehavior(annotatedIngress(tt.annotations)); got != tt.want {
+				t.Errorf("DescribeCanaryBehavior() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanaryWarnings(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantWarning bool
+	}{
+		{
+			name: "zero weight with header",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":           "true",
+				"nginx.ingress.kubernetes.io/canary-by-header": "X-Canary",
+				"nginx.ingress.kubernetes.io/canary-weight":    "0",
+			},
+			wantWarning: true,
+		},
+		{
+			name: "canary spelled 1",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":           "1",
+				"nginx.ingress.kubernetes.io/canary-by-header": "X-Canary",
+				"nginx.ingress.kubernetes.io/canary-weight":    "0",
+			},
+			wantWarning: true,
+		},
+		{
+			name: "header without weight",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":           "true",
+				"nginx.ingress.kubernetes.io/canary-by-header": "X-Canary",
+			},
+		},
+		{
+			name: "nonzero weight with header",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":           "true",
+				"nginx.ingress.kubernetes.io/canary-by-header": "X-Canary",
+				"nginx.ingress.kubernetes.io/canary-weight":    "10",
+			},
+		},
+		{
+			name: "not a canary",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary-by-header": "X-Canary",
+				"nginx.ingress.kubernetes.io/canary-weight":    "0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingress := annotatedIngress(tt.annotations)
+			if got := canaryWarnings(ingress); (len(got) > 0) != tt.wantWarning {
+				t.Errorf("canaryWarnings() = %v, want warning %v", got, tt.wantWarning)
+			}
+			if got := IngressWarnings(ingress); (len(got) > 0) != tt.wantWarning {
+				t.Errorf("IngressWarnings() = %v, want warning %v", got, tt.wantWarning)
+			}
+		})
+	}
+}
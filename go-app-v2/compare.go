@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// IngressSemanticEqual reports whether two Ingresses describe the same
+// routing: same name, namespace, labels, annotations and spec. The order of
+// TLS entries and of the hosts within them is ignored, as are status and
+// server-populated metadata.
+func IngressSemanticEqual(a, b *networkingv1.Ingress) bool {
+	if a.Name != b.Name || a.Namespace != b.Namespace {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(a.Labels, b.Labels) || !equality.Semantic.DeepEqual(a.Annotations, b.Annotations) {
+		return false
+	}
+	return equality.Semantic.DeepEqual(normalizedSpec(a), normalizedSpec(b))
+}
+
+// normalizedSpec returns a copy of the Ingress spec with TLS hosts and TLS
+// entries in a canonical order.
+func normalizedSpec(ingress *networkingv1.Ingress) networkingv1.IngressSpec {
+	spec := *ingress.Spec.DeepCopy()
+	for i := range spec.TLS {
+		sort.Strings(spec.TLS[i].Hosts)
+	}
+	sort.Slice(spec.TLS, func(i, j int) bool {
+		if spec.TLS[i].SecretName != spec.TLS[j].SecretName {
+			return spec.TLS[i].SecretName < spec.TLS[j].SecretName
+		}
+		return strings.Join(spec.TLS[i].Hosts, ",") < strings.Join(spec.TLS[j].Hosts, ",")
+	})
+	return spec
+}
+
+// NeedsUpdate compares the mutable parts of a live Ingress (labels,
+// annotations and spec) against the desired one and reports whether an update
+// is needed, along with the fields that differ. Status, resourceVersion,
+// managedFields and other server-populated metadata are ignored, as is the
+// order of TLS entries.
+func NeedsUpdate(current, desired *networkingv1.Ingress) (bool, []string) {
+	var diffs []string
+	diffs = append(diffs, mapDiff("metadata.labels", current.Labels, desired.Labels)...)
+	diffs = append(diffs, mapDiff("metadata.annotations", current.Annotations, desired.Annotations)...)
+
+	cur, want := normalizedSpec(current), normalizedSpec(desired)
+	if !equality.Semantic.DeepEqual(cur.IngressClassName, want.IngressClassName) {
+		diffs = append(diffs, "spec.ingressClassName")
+	}
+	if !equality.Semantic.DeepEqual(cur.DefaultBackend, want.DefaultBackend) {
+		diffs = append(diffs, "spec.defaultBackend")
+	}
+	if !equality.Semantic.DeepEqual(cur.Rules, want.Rules) {
+		diffs = append(diffs, "spec.rules")
+	}
+	if !equality.Semantic.DeepEqual(cur.TLS, want.TLS) {
+		diffs = append(diffs, "spec.tls")
+	}
+	return len(diffs) > 0, diffs
+}
+
+// mapDiff returns "<field>[<key>]" for every key whose value differs between
+// two string maps, in sorted key order.
+func mapDiff(field string, current, desired map[string]string) []string {
+	keys := make(map[string]bool)
+	for k := range current {
+		keys[k] = true
+	}
+	for k := range desired {
+		keys[k] = true
+	}
+
+	var diffs []string
+	for k := range keys {
+		cv, cok := current[k]
+		dv, dok := desired[k]
+		if cok != dok || cv != dv {
+			diffs = append(diffs, fmt.Sprintf("%s[%s]", field, k))
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
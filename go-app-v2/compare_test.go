@@ -0,0 +1,121 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// storefrontIngress returns a TLS ingress with two certificates.
+func storefrontIngress() *networkingv1.Ingress {
+	m := &IngressManager{}
+	ingress := m.BuildBasicIngress("storefront", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	ingress.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "true"
+	ingress.Spec.TLS = []networkingv1.IngressTLS{
+		{Hosts: []string{"api.orcapod.io"}, SecretName: "api-orcapod-tls"},
+		{Hosts: []string{"shop.orcapod.io", "www.orcapod.io"}, SecretName: "shop-orcapod-tls"},
+	}
+	return ingress
+}
+
+func TestIngressSemanticEqual(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*networkingv1.Ingress)
+		want   bool
+	}{
+		{name: "identical", mutate: func(*networkingv1.Ingress) {}, want: true},
+		{
+			name: "server-populated fields",
+			mutate: func(ing *networkingv1.Ingress) {
+				ing.ResourceVersion = "42"
+				ing.UID = "0b6f3c1e"
+				ing.Status.LoadBalancer.Ingress = []networkingv1.IngressLoadBalancerIngress{{IP: "203.0.113.10"}}
+			},
+			want: true,
+		},
+		{
+			name: "TLS order",
+			mutate: func(ing *networkingv1.Ingress) {
+				ing.Spec.TLS[0], ing.Spec.TLS[1] = ing.Spec.TLS[1], ing.Spec.TLS[0]
+				ing.Spec.TLS[0].Hosts = []string{"www.orcapod.io", "shop.orcapod.io"}
+			},
+			want: true,
+		},
+		{
+			name:   "annotation differs",
+			mutate: func(ing *networkingv1.Ingress) { ing.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "false" },
+		},
+		{
+			name:   "path differs",
+			mutate: func(ing *networkingv1.Ingress) { ing.Spec.Rules[0].HTTP.Paths[0].Path = "/shop" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := storefrontIngress()
+			b := storefrontIngress()
+			tt.mutate(b)
+			if got := IngressSemanticEqual(a, b); got != tt.want {
+				t.Errorf("IngressSemanticEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*networkingv1.Ingress)
+		want   []string
+	}{
+		{
+			name: "no drift",
+			mutate: func(ing *networkingv1.Ingress) {
+				ing.ResourceVersion = "42"
+				ing.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl"}}
+				ing.Status.LoadBalancer.Ingress = []networkingv1.IngressLoadBalancerIngress{{IP: "203.0.113.10"}}
+				ing.Spec.TLS[0], ing.Spec.TLS[1] = ing.Spec.TLS[1], ing.Spec.TLS[0]
+			},
+		},
+		{
+			name: "annotation drift",
+			mutate: func(ing *networkingv1.Ingress) {
+				ing.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "false"
+				ing.Annotations["nginx.ingress.kubernetes.io/limit-rps"] = "50"
+			},
+			want: []string{
+				"metadata.annotations[nginx.ingress.kubernetes.io/limit-rps]",
+				"metadata.annotations[nginx.ingress.kubernetes.io/ssl-redirect]",
+			},
+		},
+		{
+			name:   "label removed",
+			mutate: func(ing *networkingv1.Ingress) { ing.Labels = nil },
+			want:   []string{"metadata.labels[team]"},
+		},
+		{
+			name: "spec drift",
+			mutate: func(ing *networkingv1.Ingress) {
+				ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port.Number = 9090
+				ing.Spec.TLS = ing.Spec.TLS[:1]
+			},
+			want: []string{"spec.rules", "spec.tls"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := storefrontIngress()
+			desired.Labels = map[string]string{"team": "web"}
+			current := desired.DeepCopy()
+			tt.mutate(current)
+
+			update, diffs := NeedsUpdate(current, desired)
+			if update != (len(tt.want) > 0) || !reflect.DeepEqual(diffs, tt.want) {
+				t.Errorf("NeedsUpdate() = %v, %v, want %v", update, diffs, tt.want)
+			}
+		})
+	}
+}
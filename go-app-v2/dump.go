@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// DumpIngressAsGoLiteral renders an Ingress as a Go composite literal
+// expression, e.g. for pasting a live ingress into a table test. Only the
+// name, namespace, labels, annotations and spec are kept. The generated code
+// expects networkingv1 "k8s.io/api/networking/v1", metav1
+// "k8s.io/apimachinery/pkg/apis/meta/v1" and "k8s.io/utils/ptr" to be
+// imported, plus corev1 "k8s.io/api/core/v1" for resource backends.
+func DumpIngressAsGoLiteral(ingress *networkingv1.Ingress) string {
+	var b strings.Builder
+	b.WriteString("&networkingv1.Ingress{\n")
+	b.WriteString("ObjectMeta: metav1.ObjectMeta{\n")
+	fmt.Fprintf(&b, "Name: %q,\n", ingress.Name)
+	if ingress.Namespace != "" {
+		fmt.Fprintf(&b, "Namespace: %q,\n", ingress.Namespace)
+	}
+	writeStringMapLiteral(&b, "Labels", ingress.Labels)
+	writeStringMapLiteral(&b, "Annotations", ingress.Annotations)
+	b.WriteString("},\n")
+
+	spec := ingress.Spec
+	b.WriteString("Spec: networkingv1.IngressSpec{\n")
+	if spec.IngressClassName != nil {
+		fmt.Fprintf(&b, "IngressClassName: ptr.To(%q),\n", *spec.IngressClassName)
+	}
+	if spec.DefaultBackend != nil {
+		b.WriteString("DefaultBackend: &")
+		writeBackendLiteral(&b, *spec.DefaultBackend)
+	}
+	if len(spec.TLS) > 0 {
+		b.WriteString("TLS: []networkingv1.IngressTLS{\n")
+		for _, tls := range spec.TLS {
+			b.WriteString("{\n")
+			if len(tls.Hosts) > 0 {
+				fmt.Fprintf(&b, "Hosts: %s,\n", stringSliceLiteral(tls.Hosts))
+			}
+			if tls.SecretName != "" {
+				fmt.Fprintf(&b, "SecretName: %q,\n", tls.SecretName)
+			}
+			b.WriteString("},\n")
+		}
+		b.WriteString("},\n")
+	}
+	if len(spec.Rules) > 0 {
+		b.WriteString("Rules: []networkingv1.IngressRule{\n")
+		for _, rule := range spec.Rules {
+			b.WriteString("{\n")
+			if rule.Host != "" {
+				fmt.Fprintf(&b, "Host: %q,\n", rule.Host)
+			}
+			if rule.HTTP != nil {
+				b.WriteString("IngressRuleValue: networkingv1.IngressRuleValue{\n")
+				b.WriteString("HTTP: &networkingv1.HTTPIngressRuleValue{\n")
+				b.WriteString("Paths: []networkingv1.HTTPIngressPath{\n")
+				for _, path := range rule.HTTP.Paths {
+					b.WriteString("{\n")
+					fmt.Fprintf(&b, "Path: %q,\n", path.Path)
+					if path.PathType != nil {
+						fmt.Fprintf(&b, "PathType: ptr.To(networkingv1.PathType(%q)),\n", *path.PathType)
+					}
+					b.WriteString("Backend: ")
+					writeBackendLiteral(&b, path.Backend)
+					b.WriteString("},\n")
+				}
+				b.WriteString("},\n},\n},\n")
+			}
+			b.WriteString("},\n")
+		}
+		b.WriteString("},\n")
+	}
+	b.WriteString("},\n")
+	b.WriteString("}")
+
+	out, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return b.String()
+	}
+	return string(out)
+}
+
+// writeBackendLiteral writes an IngressBackend literal followed by ",\n".
+func writeBackendLiteral(b *strings.Builder, backend networkingv1.IngressBackend) {
+	b.WriteString("networkingv1.IngressBackend{\n")
+	if svc := backend.Service; svc != nil {
+		b.WriteString("Service: &networkingv1.IngressServiceBackend{\n")
+		fmt.Fprintf(b, "Name: %q,\n", svc.Name)
+		if svc.Port.Name != "" {
+			fmt.Fprintf(b, "Port: networkingv1.ServiceBackendPort{Name: %q},\n", svc.Port.Name)
+		} else {
+			fmt.Fprintf(b, "Port: networkingv1.ServiceBackendPort{Number: %d},\n", svc.Port.Number)
+		}
+		b.WriteString("},\n")
+	}
+	if res := backend.Resource; res != nil {
+		b.WriteString("Resource: &corev1.TypedLocalObjectReference{\n")
+		if res.APIGroup != nil {
+			fmt.Fprintf(b, "APIGroup: ptr.To(%q),\n", *res.APIGroup)
+		}
+		fmt.Fprintf(b, "Kind: %q,\n", res.Kind)
+		fmt.Fprintf(b, "Name: %q,\n", res.Name)
+		b.WriteString("},\n")
+	}
+	b.WriteString("},\n")
+}
+
+// writeStringMapLiteral writes a map[string]string field with sorted keys, or
+// nothing if m is empty.
+func writeStringMapLiteral(b *strings.Builder, field string, m map[string]string) {
+	if len(m) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(b, "%s: map[string]string{\n", field)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%q: %q,\n", k, m[k])
+	}
+	b.WriteString("},\n")
+}
+
+// stringSliceLiteral renders a []string literal on one line.
+func stringSliceLiteral(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
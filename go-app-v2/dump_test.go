@@ -0,0 +1,106 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// literalFields parses a DumpIngressAsGoLiteral expression and returns each
+// keyed field with a basic literal value as "Key=value", in source order.
+// Map entries are keyed by their string key.
+func literalFields(t *testing.T, src string) []string {
+	t.Helper()
+	expr, err := parser.ParseExpr(src)
+	if err != nil {
+		t.Fatalf("generated literal does not parse: %v\n%s", err, src)
+	}
+	var fields []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		kv, ok := n.(*ast.KeyValueExpr)
+		if !ok {
+			return true
+		}
+		value, ok := kv.Value.(*ast.BasicLit)
+		if !ok {
+			return true
+		}
+		var key string
+		switch k := kv.Key.(type) {
+		case *ast.Ident:
+			key = k.Name
+		case *ast.BasicLit:
+			key, _ = strconv.Unquote(k.Value)
+		}
+		v := value.Value
+		if value.Kind == token.STRING {
+			v, _ = strconv.Unquote(v)
+		}
+		fields = append(fields, key+"="+v)
+		return true
+	})
+	return fields
+}
+
+func TestDumpIngressAsGoLiteral(t *testing.T) {
+	m := &IngressManager{}
+
+	basic := m.BuildBasicIngress("storefront", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	basic.Labels = map[string]string{"team": "web"}
+	basic.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"shop.orcapod.io"}, SecretName: "shop-orcapod-tls"}}
+
+	named := m.BuildBasicIngressNamedPort("api", "", "api.orcapod.io", "/v1", "api-backend", "http")
+	apiGroup := "storage.k8s.io"
+	named.Spec.DefaultBackend = &networkingv1.IngressBackend{
+		Resource: &corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "Bucket", Name: "static"},
+	}
+
+	tests := []struct {
+		name    string
+		ingress *networkingv1.Ingress
+		want    []string
+	}{
+		{
+			name:    "basic",
+			ingress: basic,
+			want: []string{
+				"Name=storefront",
+				"Namespace=storefront",
+				"team=web",
+				"kubernetes.io/ingress.class=nginx",
+				"SecretName=shop-orcapod-tls",
+				"Host=shop.orcapod.io",
+				"Path=/",
+				"Name=web-frontend",
+				"Number=8080",
+			},
+		},
+		{
+			name:    "named port and resource backend",
+			ingress: named,
+			want: []string{
+				"Name=api",
+				"kubernetes.io/ingress.class=nginx",
+				"Kind=Bucket",
+				"Name=static",
+				"Host=api.orcapod.io",
+				"Path=/v1",
+				"Name=api-backend",
+				"Name=http",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := literalFields(t, DumpIngressAsGoLiteral(tt.ingress)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("literal fields = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Event reasons recorded on Ingress objects.
+const (
+	ReasonCreated          = "Created"
+	ReasonUpdated          = "Updated"
+	ReasonDeleted          = "Deleted"
+	ReasonCreateFailed     = "CreateFailed"
+	ReasonUpdateFailed     = "UpdateFailed"
+	ReasonDeleteFailed     = "DeleteFailed"
+	ReasonValidationFailed = "ValidationFailed"
+)
+
+// SetEventRecorder makes the manager emit Kubernetes Events on the Ingresses
+// it creates, updates, deletes or fails to validate. Without a recorder no
+// events are emitted.
+func (m *IngressManager) SetEventRecorder(recorder record.EventRecorder) {
+	m.recorder = recorder
+}
+
+// recordEvent emits an event on obj if a recorder is configured. obj is either
+// an Ingress returned by the API server or an ingressRef.
+func (m *IngressManager) recordEvent(obj runtime.Object, eventType, reason, messageFmt string, args ...any) {
+	if m.recorder == nil {
+		return
+	}
+	m.recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+}
+
+// recordResult emits a Normal event on result when err is nil, and a Warning
+// event on a reference to namespace/name otherwise. result is the object the
+// API server returned, so the event carries its UID.
+func (m *IngressManager) recordResult(result runtime.Object, namespace, name string, err error, okReason, failReason, verb string) {
+	if err != nil {
+		m.recordEvent(ingressRef(namespace, name), corev1.EventTypeWarning, failReason, "Failed to %s ingress: %v", verb, err)
+		return
+	}
+	m.recordEvent(result, corev1.EventTypeNormal, okReason, "Ingress %sd by provisioner", verb)
+}
+
+// ingressRef references an ingress by namespace and name only, for events
+// about one that has no server-side copy to point at: it failed validation,
+// was never created, or was just deleted. Without a UID the event is still
+// listed by kubectl describe for whichever ingress holds that name.
+func ingressRef(namespace, name string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: networkingv1.SchemeGroupVersion.String(),
+		Kind:       "Ingress",
+		Namespace:  namespace,
+		Name:       name,
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+// drainEvents returns the events a FakeRecorder has buffered so far.
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEventRecorder(t *testing.T) {
+	tests := []struct {
+		name       string
+		createErr  error
+		act        func(context.Context, *IngressManager) error
+		wantEvents []string
+	}{
+		{
+			name: "create",
+			act: func(ctx context.Context, m *IngressManager) error {
+				_, err := m.CreateIngress(ctx, m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080))
+				return err
+			},
+			wantEvents: []string{"Normal Created Ingress created by provisioner"},
+		},
+		{
+			name:      "create fails",
+			createErr: apierrors.NewForbidden(schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}, "web", errors.New("denied")),
+			act: func(ctx context.Context, m *IngressManager) error {
+				_, err := m.CreateIngress(ctx, m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080))
+				return err
+			},
+			wantEvents: []string{"Warning CreateFailed Failed to create ingress"},
+		},
+		{
+			name: "create then delete",
+			act: func(ctx context.Context, m *IngressManager) error {
+				if _, err := m.CreateIngress(ctx, m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)); err != nil {
+					return err
+				}
+				return m.DeleteIngress(ctx, "storefront", "web")
+			},
+			wantEvents: []string{"Normal Created Ingress created by provisioner", "Normal Deleted Ingress deleted by provisioner"},
+		},
+		{
+			name: "validation failure",
+			act: func(ctx context.Context, m *IngressManager) error {
+				ingress := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web_frontend", 8080)
+				return m.validateForProvision(ingress)
+			},
+			wantEvents: []string{"Warning ValidationFailed Validation failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			if tt.createErr != nil {
+				clientset.PrependReactor("create", "ingresses", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tt.createErr
+				})
+			}
+			m := NewIngressManager(clientset)
+			recorder := record.NewFakeRecorder(10)
+			m.SetEventRecorder(recorder)
+
+			err := tt.act(context.Background(), m)
+			if wantErr := strings.HasPrefix(tt.wantEvents[0], "Warning"); (err != nil) != wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, wantErr)
+			}
+			events := drainEvents(recorder)
+			if len(events) != len(tt.wantEvents) {
+				t.Fatalf("events = %q, want %d", events, len(tt.wantEvents))
+			}
+			for i, want := range tt.wantEvents {
+				if !strings.HasPrefix(events[i], want) {
+					t.Errorf("event %d = %q, want prefix %q", i, events[i], want)
+				}
+			}
+		})
+	}
+
+	t.Run("no recorder", func(t *testing.T) {
+		m := NewIngressManager(fake.NewSimpleClientset())
+		if _, err := m.CreateIngress(context.Background(), m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)); err != nil {
+			t.Fatalf("CreateIngress() error = %v", err)
+		}
+	})
+
+	t.Run("events reference the ingress", func(t *testing.T) {
+		m := NewIngressManager(fake.NewSimpleClientset())
+		recorder := record.NewFakeRecorder(10)
+		recorder.IncludeObject = true
+		m.SetEventRecorder(recorder)
+		if err := m.DeleteIngress(context.Background(), "storefront", "web"); err == nil {
+			t.Fatal("DeleteIngress() of a missing ingress succeeded")
+		}
+		events := drainEvents(recorder)
+		want := []string{"involvedObject{kind=Ingress,apiVersion=networking.k8s.io/v1}"}
+		var got []string
+		for _, e := range events {
+			if i := strings.Index(e, "involvedObject"); i >= 0 {
+				got = append(got, e[i:])
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("events = %q, want involved object %q", events, want)
+		}
+	})
+}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+	gatewayclientset "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
+)
+
+// errNoGatewayClient is returned by Gateway API methods when the manager was
+// built without a gateway-api clientset.
+var errNoGatewayClient = errors.New("gateway API client not configured; use NewIngressManagerWithGateway")
+
+// NewIngressManagerWithGateway creates an IngressManager that can also manage
+// Gateway API resources.
+func NewIngressManagerWithGateway(clientset kubernetes.Interface, gwclient gatewayclientset.Interface) *IngressManager {
+	return &IngressManager{clientset: clientset, gwclient: gwclient}
+}
+
+// CreateHTTPRoute creates a new HTTPRoute resource in the cluster.
+func (m *IngressManager) CreateHTTPRoute(ctx context.Context, route *gatewayv1.HTTPRoute) (*gatewayv1.HTTPRoute, error) {
+	if m.gwclient == nil {
+		return nil, errNoGatewayClient
+	}
+	return m.gwclient.GatewayV1().HTTPRoutes(route.Namespace).Create(ctx, route, metav1.CreateOptions{})
+}
+
+// CreateGateway creates a new Gateway resource in the cluster.
+func (m *IngressManager) CreateGateway(ctx context.Context, gateway *gatewayv1.Gateway) (*gatewayv1.Gateway, error) {
+	if m.gwclient == nil {
+		return nil, errNoGatewayClient
+	}
+	return m.gwclient.GatewayV1().Gateways(gateway.Namespace).Create(ctx, gateway, metav1.CreateOptions{})
+}
+
+// CreateReferenceGrant creates a new ReferenceGrant resource in the cluster.
+func (m *IngressManager) CreateReferenceGrant(ctx context.Context, grant *gatewayv1beta1.ReferenceGrant) (*gatewayv1beta1.ReferenceGrant, error) {
+	if m.gwclient == nil {
+		return nil, errNoGatewayClient
+	}
+	return m.gwclient.GatewayV1beta1().ReferenceGrants(grant.Namespace).Create(ctx, grant, metav1.CreateOptions{})
+}
+
+// ReconcileHTTPRoute brings an HTTPRoute in line with desired: it creates the
+// route if it doesn't exist and replaces its spec if it differs, reporting
+// whether anything changed. Only the spec is compared, so status and metadata
+// such as managedFields never trigger an update. The update carries the live
+// resourceVersion, so a concurrent change makes it fail with a conflict rather
+// than being overwritten. desired should spell out fields the API server
+// defaults (backendRef kind, weight, ...); otherwise every call sees drift.
+func (m *IngressManager) ReconcileHTTPRoute(ctx context.Context, desired *gatewayv1.HTTPRoute) (*gatewayv1.HTTPRoute, bool, error) {
+	if m.gwclient == nil {
+		return nil, false, errNoGatewayClient
+	}
+	routes := m.gwclient.GatewayV1().HTTPRoutes(desired.Namespace)
+
+	existing, err := routes.Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		created, err := routes.Create(ctx, desired, metav1.CreateOptions{})
+		if err != nil {
+			return nil, false, err
+		}
+		return created, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if equality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
+		return existing, false, nil
+	}
+
+	route := existing.DeepCopy()
+	route.Spec = *desired.Spec.DeepCopy()
+	updated, err := routes.Update(ctx, route, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to update HTTPRoute %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+	return updated, true, nil
+}
+
+// SecretsNeedingGrants lists the certificate Secrets referenced by a Gateway's
+// listeners that live outside gatewayNamespace. Only those need a
+// ReferenceGrant; same-namespace Secrets are always allowed. Refs with no
+// namespace resolve to gatewayNamespace, and refs to kinds other than core
+// Secrets are skipped. Each Secret is listed once, in listener order.
+func SecretsNeedingGrants(gw *gatewayv1.Gateway, gatewayNamespace string) []types.NamespacedName {
+	var secrets []types.NamespacedName
+	seen := make(map[types.NamespacedName]bool)
+	for _, listener := range gw.Spec.Listeners {
+		if listener.TLS == nil {
+			continue
+		}
+		for _, ref := range listener.TLS.CertificateRefs {
+			if ref.Group != nil && *ref.Group != "" {
+				continue
+			}
+			if ref.Kind != nil && *ref.Kind != "Secret" {
+				continue
+			}
+			namespace := gatewayNamespace
+			if ref.Namespace != nil && *ref.Namespace != "" {
+				namespace = string(*ref.Namespace)
+			}
+			if namespace == gatewayNamespace {
+				continue
+			}
+			key := types.NamespacedName{Namespace: namespace, Name: string(ref.Name)}
+			if !seen[key] {
+				seen[key] = true
+				secrets = append(secrets, key)
+			}
+		}
+	}
+	return secrets
+}
+
+// RouteParentStatus summarizes the status an HTTPRoute reports for one parent
+// Gateway.
+type RouteParentStatus struct {
+	ParentRef      gatewayv1.ParentReference
+	ControllerName string
+	Accepted       bool
+	ResolvedRefs   bool
+}
+
+// GetHTTPRouteStatus reads an HTTPRoute and reports, per parent Gateway,
+// whether the route is Accepted and has its references resolved.
+func (m *IngressManager) GetHTTPRouteStatus(ctx context.Context, namespace, name string) ([]RouteParentStatus, error) {
+	if m.gwclient == nil {
+		return nil, errNoGatewayClient
+	}
+	route, err := m.gwclient.GatewayV1().HTTPRoutes(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]RouteParentStatus, 0, len(route.Status.Parents))
+	for _, parent := range route.Status.Parents {
+		statuses = append(statuses, RouteParentStatus{
+			ParentRef:      parent.ParentRef,
+			ControllerName: string(parent.ControllerName),
+			Accepted:       meta.IsStatusConditionTrue(parent.Conditions, string(gatewayv1.RouteConditionAccepted)),
+			ResolvedRefs:   meta.IsStatusConditionTrue(parent.Conditions, string(gatewayv1.RouteConditionResolvedRefs)),
+		})
+	}
+	return statuses, nil
+}
+
+// routeNameHashLen is the number of hex characters of the name hash appended
+// when an HTTPRoute name has to be truncated.
+const routeNameHashLen = 10
+
+// defaultRouteName is the base HTTPRouteName uses when both the ingress name
+// and the suffix sanitize to nothing.
+const defaultRouteName = "route"
+
+// HTTPRouteName derives a DNS-1123 subdomain name for an HTTPRoute generated
+// from an Ingress, e.g. HTTPRouteName("storefront", "redirect") returns
+// "storefront-redirect". An empty ingress name leaves just the suffix, and if
+// that is empty too the name is "route". Names longer than 253 characters are
+// truncated and given a hash of the full name, so the result is stable across
+// runs.
+func HTTPRouteName(ingressName, suffix string) string {
+	var parts []string
+	for _, p := range []string{ingressName, suffix} {
+		if s := sanitizeDNSSubdomain(p); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	name := strings.Join(parts, "-")
+	if name == "" {
+		return defaultRouteName
+	}
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:routeNameHashLen]
+	prefix := strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-routeNameHashLen-1], "-")
+	return prefix + "-" + hash
+}
+
+// sanitizeDNSSubdomain lowercases s and replaces anything other than
+// alphanumerics and '-' with '-', trimming dashes from the ends. Dots are
+// replaced too so no label can end up starting or ending with a dash.
+func sanitizeDNSSubdomain(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, s)
+	return strings.Trim(s, "-")
+}
+
+// Gateway API limits checked by ValidateHTTPRoute, as set by the v1.2 CRD
+// schema.
+const (
+	maxHTTPRouteRules        = 16
+	maxHTTPRouteMatches      = 64
+	maxHTTPRouteTotalMatches = 128
+	maxHTTPRouteHostnames    = 16
+	maxBackendWeight         = 1000000
+)
+
+// uniqueHTTPRouteFilters are the filter types that may appear at most once per
+// rule; RequestMirror and ExtensionRef may repeat.
+var uniqueHTTPRouteFilters = map[gatewayv1.HTTPRouteFilterType]bool{
+	gatewayv1.HTTPRouteFilterRequestHeaderModifier:  true,
+	gatewayv1.HTTPRouteFilterResponseHeaderModifier: true,
+	gatewayv1.HTTPRouteFilterRequestRedirect:        true,
+	gatewayv1.HTTPRouteFilterURLRewrite:             true,
+}
+
+// ValidateHTTPRoute checks an HTTPRoute offline against the Gateway API field
+// constraints a controller or the CRD schema would enforce: rule, match and
+// hostname counts (including the 128-match total across rules), hostname
+// format, repeated filter types within a rule and backend weight range. It
+// returns every violation found.
+func ValidateHTTPRoute(route *gatewayv1.HTTPRoute) []string {
+	var violations []string
+
+	if n := len(route.Spec.Hostnames); n > maxHTTPRouteHostnames {
+		violations = append(violations, fmt.Sprintf("spec.hostnames: %d hostnames exceeds the limit of %d", n, maxHTTPRouteHostnames))
+	}
+	for i, h := range route.Spec.Hostnames {
+		host := strings.TrimPrefix(string(h), "*.")
+		if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+			violations = append(violations, fmt.Sprintf("spec.hostnames[%d]: %q is not a valid hostname: %s", i, h, strings.Join(errs, "; ")))
+		}
+	}
+
+	if n := len(route.Spec.Rules); n > maxHTTPRouteRules {
+		violations = append(violations, fmt.Sprintf("spec.rules: %d rules exceeds the limit of %d", n, maxHTTPRouteRules))
+	}
+	totalMatches := 0
+	for _, rule := range route.Spec.Rules {
+		totalMatches += len(rule.Matches)
+	}
+	if totalMatches > maxHTTPRouteTotalMatches {
+		violations = append(violations, fmt.Sprintf("spec.rules: %d matches across all rules exceeds the limit of %d", totalMatches, maxHTTPRouteTotalMatches))
+	}
+	for i, rule := range route.Spec.Rules {
+		field := fmt.Sprintf("spec.rules[%d]", i)
+		if n := len(rule.Matches); n > maxHTTPRouteMatches {
+			violations = append(violations, fmt.Sprintf("%s.matches: %d matches exceeds the limit of %d", field, n, maxHTTPRouteMatches))
+		}
+
+		seen := make(map[gatewayv1.HTTPRouteFilterType]bool)
+		for _, f := range rule.Filters {
+			if uniqueHTTPRouteFilters[f.Type] && seen[f.Type] {
+				violations = append(violations, fmt.Sprintf("%s.filters: %s may appear only once per rule", field, f.Type))
+			}
+			seen[f.Type] = true
+		}
+
+		for j, ref := range rule.BackendRefs {
+			if ref.Weight == nil {
+				continue
+			}
+			if w := *ref.Weight; w < 0 || w > maxBackendWeight {
+				violations = append(violations, fmt.Sprintf("%s.backendRefs[%d].weight: %d is outside 0-%d", field, j, w, maxBackendWeight))
+			}
+		}
+	}
+
+	return violations
+}
+
+// MergeHTTPRoutes combines routes that share a namespace, hostnames and
+// parentRefs into a single route named after the first one. Rules are
+// concatenated and then ordered from most to least specific match, following
+// Gateway API precedence: Exact paths before prefixes, longer paths first, then
+// more header and query param matches. Routes with different namespaces,
+// hostnames or parentRefs are an error. Check the result with
+// ValidateHTTPRoute, since merging can exceed the rule limit.
+func MergeHTTPRoutes(routes []*gatewayv1.HTTPRoute) (*gatewayv1.HTTPRoute, error) {
+	if len(routes) == 0 {
+		return nil, errors.New("no HTTPRoutes to merge")
+	}
+
+	first := routes[0]
+	hostnames := sortedHostnames(first.Spec.Hostnames)
+	base := first.DeepCopy()
+	merged := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        base.Name,
+			Namespace:   base.Namespace,
+			Labels:      base.Labels,
+			Annotations: base.Annotations,
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			CommonRouteSpec: base.Spec.CommonRouteSpec,
+			Hostnames:       base.Spec.Hostnames,
+		},
+	}
+
+	for _, route := range routes {
+		if route.Namespace != first.Namespace {
+			return nil, fmt.Errorf("HTTPRoute %s/%s is in a different namespace from %s/%s", route.Namespace, route.Name, first.Namespace, first.Name)
+		}
+		if !reflect.DeepEqual(sortedHostnames(route.Spec.Hostnames), hostnames) {
+			return nil, fmt.Errorf("HTTPRoute %s/%s has different hostnames from %s/%s", route.Namespace, route.Name, first.Namespace, first.Name)
+		}
+		if !reflect.DeepEqual(route.Spec.ParentRefs, first.Spec.ParentRefs) {
+			return nil, fmt.Errorf("HTTPRoute %s/%s has conflicting parentRefs with %s/%s", route.Namespace, route.Name, first.Namespace, first.Name)
+		}
+		for _, rule := range route.Spec.Rules {
+			merged.Spec.Rules = append(merged.Spec.Rules, *rule.DeepCopy())
+		}
+	}
+
+	sort.SliceStable(merged.Spec.Rules, func(i, j int) bool {
+		return ruleSpecificity(merged.Spec.Rules[i]).moreSpecific(ruleSpecificity(merged.Spec.Rules[j]))
+	})
+	return merged, nil
+}
+
+func sortedHostnames(hostnames []gatewayv1.Hostname) []gatewayv1.Hostname {
+	out := append([]gatewayv1.Hostname(nil), hostnames...)
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// matchSpecificity ranks an HTTPRouteMatch by Gateway API precedence.
+type matchSpecificity struct {
+	exact   bool
+	pathLen int
+	headers int
+	queries int
+}
+
+func (a matchSpecificity) moreSpecific(b matchSpecificity) bool {
+	if a.exact != b.exact {
+		return a.exact
+	}
+	if a.pathLen != b.pathLen {
+		return a.pathLen > b.pathLen
+	}
+	if a.headers != b.headers {
+		return a.headers > b.headers
+	}
+	return a.queries > b.queries
+}
+
+// ruleSpecificity returns the specificity of a rule's most specific match. A
+// rule with no matches matches every request, like a "/" prefix.
+func ruleSpecificity(rule gatewayv1.HTTPRouteRule) matchSpecificity {
+	best := matchSpecificity{pathLen: 1}
+	for i, match := range rule.Matches {
+		s := matchSpecificity{pathLen: 1, headers: len(match.Headers), queries: len(match.QueryParams)}
+		if match.Path != nil {
+			if match.Path.Value != nil {
+				s.pathLen = len(*match.Path.Value)
+			}
+			s.exact = match.Path.Type != nil && *match.Path.Type == gatewayv1.PathMatchExact
+		}
+		if i == 0 || s.moreSpecific(best) {
+			best = s
+		}
+	}
+	return best
+}
+
+// WeightedBackend is one Service receiving a share of a route's traffic.
+type WeightedBackend struct {
+	Service string
+	Port    int32
+	Weight  int32
+}
+
+// ValidateWeightedBackends checks that every weight is within the Gateway API
+// range of 0-1000000 and that at least one backend receives traffic. The total
+// is summed as int64 so large weights can't wrap around.
+func ValidateWeightedBackends(backends []WeightedBackend) error {
+	var total int64
+	for _, b := range backends {
+		if b.Weight < 0 {
+			return fmt.Errorf("backend %s has negative weight %d", b.Service, b.Weight)
+		}
+		if b.Weight > maxBackendWeight {
+			return fmt.Errorf("backend %s has weight %d above the maximum of %d", b.Service, b.Weight, maxBackendWeight)
+		}
+		total += int64(b.Weight)
+	}
+	if total == 0 {
+		return fmt.Errorf("at least one backend must have a positive weight")
+	}
+	return nil
+}
+
+// BuildWeightedRoute creates an HTTPRoute with a single host and path prefix
+// rule that splits traffic across the given backends by weight. ParentRefs are
+// left for the caller to set; check backends with ValidateWeightedBackends
+// first.
+func BuildWeightedRoute(name, namespace, host, path string, backends []WeightedBackend) *gatewayv1.HTTPRoute {
+	pathType := gatewayv1.PathMatchPathPrefix
+
+	refs := make([]gatewayv1.HTTPBackendRef, 0, len(backends))
+	for _, b := range backends {
+		port := gatewayv1.PortNumber(b.Port)
+		weight := b.Weight
+		refs = append(refs, gatewayv1.HTTPBackendRef{
+			BackendRef: gatewayv1.BackendRef{
+				BackendObjectReference: gatewayv1.BackendObjectReference{
+					Name: gatewayv1.ObjectName(b.Service),
+					Port: &port,
+				},
+				Weight: &weight,
+			},
+		})
+	}
+
+	return &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{gatewayv1.Hostname(host)},
+			Rules: []gatewayv1.HTTPRouteRule{
+				{
+					Matches: []gatewayv1.HTTPRouteMatch{
+						{
+							Path: &gatewayv1.HTTPPathMatch{
+								Type:  &pathType,
+								Value: &path,
+							},
+						},
+					},
+					BackendRefs: refs,
+				},
+			},
+		},
+	}
+}
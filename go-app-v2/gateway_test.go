@@ -0,0 +1,524 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/fake"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+	gwfake "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned/fake"
+)
+
+// newGatewayTestManager returns a manager backed by fake Kubernetes and
+// gateway-api clientsets seeded with gwObjects.
+func newGatewayTestManager(gwObjects ...runtime.Object) (*IngressManager, *gwfake.Clientset) {
+	gwclient := gwfake.NewSimpleClientset(gwObjects...)
+	return NewIngressManagerWithGateway(fake.NewSimpleClientset(), gwclient), gwclient
+}
+
+// routeParentStatus builds the status a Gateway controller reports for one
+// parent of an HTTPRoute.
+func routeParentStatus(gateway string, accepted metav1.ConditionStatus) gatewayv1.RouteParentStatus {
+	return gatewayv1.RouteParentStatus{
+		ParentRef:      gatewayv1.ParentReference{Name: gatewayv1.ObjectName(gateway)},
+		ControllerName: "example.com/gateway-controller",
+		Conditions: []metav1.Condition{
+			{Type: string(gatewayv1.RouteConditionAccepted), Status: accepted, Reason: "Test"},
+			{Type: string(gatewayv1.RouteConditionResolvedRefs), Status: metav1.ConditionTrue, Reason: "Test"},
+		},
+	}
+}
+
+func TestGetHTTPRouteStatus(t *testing.T) {
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "storefront", Namespace: "storefront"},
+		Status: gatewayv1.HTTPRouteStatus{
+			RouteStatus: gatewayv1.RouteStatus{
+				Parents: []gatewayv1.RouteParentStatus{
+					routeParentStatus("public", metav1.ConditionTrue),
+					routeParentStatus("internal", metav1.ConditionFalse),
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		manager   func() *IngressManager
+		routeName string
+		want      []bool
+		wantErr   func(error) bool
+	}{
+		{
+			name:      "accepted by one parent",
+			manager:   func() *IngressManager { m, _ := newGatewayTestManager(route); return m },
+			routeName: "storefront",
+			want:      []bool{true, false},
+		},
+		{
+			name:      "route not found",
+			manager:   func() *IngressManager { m, _ := newGatewayTestManager(); return m },
+			routeName: "storefront",
+			wantErr:   apierrors.IsNotFound,
+		},
+		{
+			name:      "no gateway client",
+			manager:   func() *IngressManager { return NewIngressManager(fake.NewSimpleClientset()) },
+			routeName: "storefront",
+			wantErr:   func(err error) bool { return errors.Is(err, errNoGatewayClient) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses, err := tt.manager().GetHTTPRouteStatus(context.Background(), "storefront", tt.routeName)
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("GetHTTPRouteStatus() error = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetHTTPRouteStatus() error = %v", err)
+			}
+			if len(statuses) != len(tt.want) {
+				t.Fatalf("got %d parent statuses, want %d", len(statuses), len(tt.want))
+			}
+			for i, st := range statuses {
+				if st.Accepted != tt.want[i] {
+					t.Errorf("parent %s Accepted = %v, want %v", st.ParentRef.Name, st.Accepted, tt.want[i])
+				}
+				if !st.ResolvedRefs {
+					t.Errorf("parent %s ResolvedRefs = false, want true", st.ParentRef.Name)
+				}
+				if st.ControllerName != "example.com/gateway-controller" {
+					t.Errorf("parent %s ControllerName = %q", st.ParentRef.Name, st.ControllerName)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateGatewayObjects(t *testing.T) {
+	meta := metav1.ObjectMeta{Name: "storefront", Namespace: "storefront"}
+	tests := []struct {
+		name   string
+		create func(context.Context, *IngressManager) error
+		get    func(context.Context, *gwfake.Clientset) error
+	}{
+		{
+			name: "HTTPRoute",
+			create: func(ctx context.Context, m *IngressManager) error {
+				_, err := m.CreateHTTPRoute(ctx, &gatewayv1.HTTPRoute{ObjectMeta: meta})
+				return err
+			},
+			get: func(ctx context.Context, c *gwfake.Clientset) error {
+				_, err := c.GatewayV1().HTTPRoutes(meta.Namespace).Get(ctx, meta.Name, metav1.GetOptions{})
+				return err
+			},
+		},
+		{
+			name: "Gateway",
+			create: func(ctx context.Context, m *IngressManager) error {
+				_, err := m.CreateGateway(ctx, &gatewayv1.Gateway{ObjectMeta: meta})
+				return err
+			},
+			get: func(ctx context.Context, c *gwfake.Clientset) error {
+				_, err := c.GatewayV1().Gateways(meta.Namespace).Get(ctx, meta.Name, metav1.GetOptions{})
+				return err
+			},
+		},
+		{
+			name: "ReferenceGrant",
+			create: func(ctx context.Context, m *IngressManager) error {
+				_, err := m.CreateReferenceGrant(ctx, &gatewayv1beta1.ReferenceGrant{ObjectMeta: meta})
+				return err
+			},
+			get: func(ctx context.Context, c *gwfake.Clientset) error {
+				_, err := c.GatewayV1beta1().ReferenceGrants(meta.Namespace).Get(ctx, meta.Name, metav1.GetOptions{})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, gwclient := newGatewayTestManager()
+			if err := tt.create(ctx, m); err != nil {
+				t.Fatalf("create error = %v", err)
+			}
+			if err := tt.get(ctx, gwclient); err != nil {
+				t.Errorf("created object not found: %v", err)
+			}
+			if err := tt.create(ctx, m); !apierrors.IsAlreadyExists(err) {
+				t.Errorf("second create error = %v, want AlreadyExists", err)
+			}
+
+			if err := tt.create(ctx, NewIngressManager(fake.NewSimpleClientset())); !errors.Is(err, errNoGatewayClient) {
+				t.Errorf("create without gateway client error = %v, want %v", err, errNoGatewayClient)
+			}
+		})
+	}
+}
+
+func TestHTTPRouteName(t *testing.T) {
+	long := strings.Repeat("checkout-service-", 20)
+	tests := []struct {
+		name        string
+		ingressName string
+		suffix      string
+		want        string
+	}{
+		{name: "simple suffix", ingressName: "storefront", suffix: "redirect", want: "storefront-redirect"},
+		{name: "no suffix", ingressName: "storefront", want: "storefront"},
+		{name: "sanitized", ingressName: "Storefront_API", suffix: "v2.beta", want: "storefront-api-v2-beta"},
+		{name: "empty ingress name", suffix: "redirect", want: "redirect"},
+		{name: "nothing left", ingressName: "--", suffix: "..", want: "route"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTTPRouteName(tt.ingressName, tt.suffix); got != tt.want {
+				t.Errorf("HTTPRouteName(%q, %q) = %q, want %q", tt.ingressName, tt.suffix, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("long name gets a hash suffix", func(t *testing.T) {
+		got := HTTPRouteName(long, "redirect")
+		if len(got) > validation.DNS1123SubdomainMaxLength {
+			t.Fatalf("len = %d, want at most %d", len(got), validation.DNS1123SubdomainMaxLength)
+		}
+		if errs := validation.IsDNS1123Subdomain(got); len(errs) > 0 {
+			t.Errorf("%q is not a DNS-1123 subdomain: %v", got, errs)
+		}
+		if again := HTTPRouteName(long, "redirect"); again != got {
+			t.Errorf("second call = %q, want the same name %q", again, got)
+		}
+		if other := HTTPRouteName(long, "canary"); other == got {
+			t.Errorf("different suffixes both produced %q", got)
+		}
+	})
+}
+
+func TestBuildWeightedRoute(t *testing.T) {
+	backends := []WeightedBackend{
+		{Service: "web-frontend", Port: 8080, Weight: 70},
+		{Service: "web-frontend-v2", Port: 8080, Weight: 30},
+	}
+	if err := ValidateWeightedBackends(backends); err != nil {
+		t.Fatalf("ValidateWeightedBackends() = %v", err)
+	}
+
+	route := BuildWeightedRoute("storefront", "storefront", "shop.orcapod.io", "/", backends)
+	if len(route.Spec.Rules) != 1 {
+		t.Fatalf("got %d rules, want 1", len(route.Spec.Rules))
+	}
+	refs := route.Spec.Rules[0].BackendRefs
+	if len(refs) != len(backends) {
+		t.Fatalf("got %d backendRefs, want %d", len(refs), len(backends))
+	}
+	for i, ref := range refs {
+		if string(ref.Name) != backends[i].Service || *ref.Port != gatewayv1.PortNumber(backends[i].Port) || *ref.Weight != backends[i].Weight {
+			t.Errorf("backendRefs[%d] = %s:%d weight %d, want %+v", i, ref.Name, *ref.Port, *ref.Weight, backends[i])
+		}
+	}
+	if violations := ValidateHTTPRoute(route); len(violations) > 0 {
+		t.Errorf("ValidateHTTPRoute() = %v, want none", violations)
+	}
+}
+
+func TestValidateWeightedBackends(t *testing.T) {
+	// 4294 backends at the maximum weight plus one at 967296 sum to exactly
+	// 2^32, which wraps an int32 total around to 0.
+	wrapsToZero := make([]WeightedBackend, 0, 4295)
+	for i := 0; i < 4294; i++ {
+		wrapsToZero = append(wrapsToZero, WeightedBackend{Service: fmt.Sprintf("shard-%d", i), Weight: maxBackendWeight})
+	}
+	wrapsToZero = append(wrapsToZero, WeightedBackend{Service: "shard-last", Weight: 967296})
+
+	tests := []struct {
+		name     string
+		backends []WeightedBackend
+		wantErr  bool
+	}{
+		{name: "70/30", backends: []WeightedBackend{{Service: "a", Weight: 70}, {Service: "b", Weight: 30}}},
+		{name: "zero weight standby", backends: []WeightedBackend{{Service: "a", Weight: 100}, {Service: "b", Weight: 0}}},
+		{name: "negative weight", backends: []WeightedBackend{{Service: "a", Weight: 100}, {Service: "b", Weight: -1}}, wantErr: true},
+		{name: "all zero", backends: []WeightedBackend{{Service: "a"}, {Service: "b"}}, wantErr: true},
+		{name: "no backends", wantErr: true},
+		{name: "weight at maximum", backends: []WeightedBackend{{Service: "a", Weight: maxBackendWeight}}},
+		{name: "weight above maximum", backends: []WeightedBackend{{Service: "a", Weight: maxBackendWeight + 1}}, wantErr: true},
+		{name: "int32 max weights", backends: []WeightedBackend{{Service: "a", Weight: math.MaxInt32}, {Service: "b", Weight: math.MaxInt32}}, wantErr: true},
+		{name: "total past int32", backends: wrapsToZero},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateWeightedBackends(tt.backends); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWeightedBackends() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReconcileHTTPRoute(t *testing.T) {
+	desired := func() *gatewayv1.HTTPRoute {
+		return BuildWeightedRoute("storefront", "storefront", "shop.orcapod.io", "/", []WeightedBackend{
+			{Service: "web-frontend", Port: 8080, Weight: 70},
+			{Service: "web-frontend-v2", Port: 8080, Weight: 30},
+		})
+	}
+
+	unchanged := desired()
+	unchanged.ResourceVersion = "7"
+	unchanged.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl"}}
+	unchanged.Status.Parents = []gatewayv1.RouteParentStatus{routeParentStatus("public", metav1.ConditionTrue)}
+
+	extraHost := desired()
+	extraHost.Spec.Hostnames = append(extraHost.Spec.Hostnames, "www.orcapod.io")
+
+	extraRule := desired()
+	extraRule.Spec.Rules = append(extraRule.Spec.Rules, *extraRule.Spec.Rules[0].DeepCopy())
+
+	tests := []struct {
+		name        string
+		existing    []runtime.Object
+		wantChanged bool
+		wantWrites  []string
+	}{
+		{name: "create", wantChanged: true, wantWrites: []string{"create"}},
+		{name: "no-op", existing: []runtime.Object{unchanged}},
+		{name: "hostname removed", existing: []runtime.Object{extraHost}, wantChanged: true, wantWrites: []string{"update"}},
+		{name: "rule removed", existing: []runtime.Object{extraRule}, wantChanged: true, wantWrites: []string{"update"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, gwclient := newGatewayTestManager(tt.existing...)
+
+			route, changed, err := m.ReconcileHTTPRoute(ctx, desired())
+			if err != nil {
+				t.Fatalf("ReconcileHTTPRoute() error = %v", err)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			var writes []string
+			for _, action := range gwclient.Actions() {
+				if verb := action.GetVerb(); verb == "create" || verb == "update" || verb == "patch" {
+					writes = append(writes, verb)
+				}
+			}
+			if !reflect.DeepEqual(writes, tt.wantWrites) {
+				t.Errorf("writes = %v, want %v", writes, tt.wantWrites)
+			}
+
+			live, err := gwclient.GatewayV1().HTTPRoutes("storefront").Get(ctx, "storefront", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(live.Spec, desired().Spec) || !reflect.DeepEqual(route.Spec, live.Spec) {
+				t.Errorf("live spec = %+v, want %+v", live.Spec, desired().Spec)
+			}
+		})
+	}
+
+	t.Run("no gateway client", func(t *testing.T) {
+		m := NewIngressManager(fake.NewSimpleClientset())
+		if _, _, err := m.ReconcileHTTPRoute(context.Background(), desired()); !errors.Is(err, errNoGatewayClient) {
+			t.Errorf("ReconcileHTTPRoute() error = %v, want %v", err, errNoGatewayClient)
+		}
+	})
+}
+
+func TestValidateHTTPRoute(t *testing.T) {
+	clean := func() *gatewayv1.HTTPRoute {
+		return BuildWeightedRoute("storefront", "storefront", "shop.orcapod.io", "/", []WeightedBackend{
+			{Service: "web-frontend", Port: 8080, Weight: 100},
+		})
+	}
+	withMatches := func(rules, matchesPerRule int) *gatewayv1.HTTPRoute {
+		route := clean()
+		rule := route.Spec.Rules[0]
+		rule.Matches = make([]gatewayv1.HTTPRouteMatch, matchesPerRule)
+		route.Spec.Rules = nil
+		for i := 0; i < rules; i++ {
+			route.Spec.Rules = append(route.Spec.Rules, *rule.DeepCopy())
+		}
+		return route
+	}
+	negative := int32(-1)
+
+	tests := []struct {
+		name   string
+		route  *gatewayv1.HTTPRoute
+		mutate func(*gatewayv1.HTTPRoute)
+		want   []string
+	}{
+		{name: "clean", route: clean()},
+		{name: "at the limits", route: withMatches(16, 8)},
+		{name: "too many rules", route: withMatches(17, 1), want: []string{"spec.rules: 17 rules exceeds the limit of 16"}},
+		{name: "too many matches in total", route: withMatches(3, 43), want: []string{"spec.rules: 129 matches across all rules exceeds the limit of 128"}},
+		{name: "too many matches in a rule", route: withMatches(1, 65), want: []string{"spec.rules[0].matches: 65 matches exceeds the limit of 64"}},
+		{
+			name:   "bad hostname",
+			route:  clean(),
+			mutate: func(r *gatewayv1.HTTPRoute) { r.Spec.Hostnames = []gatewayv1.Hostname{"*.orcapod.io", "Shop_Orcapod"} },
+			want:   []string{`spec.hostnames[1]: "Shop_Orcapod" is not a valid hostname`},
+		},
+		{
+			name:  "repeated filter",
+			route: clean(),
+			mutate: func(r *gatewayv1.HTTPRoute) {
+				r.Spec.Rules[0].Filters = []gatewayv1.HTTPRouteFilter{
+					{Type: gatewayv1.HTTPRouteFilterResponseHeaderModifier},
+					{Type: gatewayv1.HTTPRouteFilterExtensionRef},
+					{Type: gatewayv1.HTTPRouteFilterExtensionRef},
+					{Type: gatewayv1.HTTPRouteFilterResponseHeaderModifier},
+				}
+			},
+			want: []string{"spec.rules[0].filters: ResponseHeaderModifier may appear only once per rule"},
+		},
+		{
+			name:   "negative weight",
+			route:  clean(),
+			mutate: func(r *gatewayv1.HTTPRoute) { r.Spec.Rules[0].BackendRefs[0].Weight = &negative },
+			want:   []string{"spec.rules[0].backendRefs[0].weight: -1 is outside 0-1000000"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.mutate != nil {
+				tt.mutate(tt.route)
+			}
+			got := ValidateHTTPRoute(tt.route)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ValidateHTTPRoute() = %q, want %q", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(got[i], want) {
+					t.Errorf("violation %d = %q, want prefix %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretsNeedingGrants(t *testing.T) {
+	certRef := func(namespace, name string) gatewayv1.SecretObjectReference {
+		ref := gatewayv1.SecretObjectReference{Name: gatewayv1.ObjectName(name)}
+		if namespace != "" {
+			ns := gatewayv1.Namespace(namespace)
+			ref.Namespace = &ns
+		}
+		return ref
+	}
+	configMap := certRef("storefront", "ca-bundle")
+	kind := gatewayv1.Kind("ConfigMap")
+	configMap.Kind = &kind
+
+	tests := []struct {
+		name string
+		refs []gatewayv1.SecretObjectReference
+		want []types.NamespacedName
+	}{
+		{
+			name: "same namespace",
+			refs: []gatewayv1.SecretObjectReference{certRef("", "shop-tls"), certRef("gateway", "api-tls")},
+		},
+		{
+			name: "cross namespace",
+			refs: []gatewayv1.SecretObjectReference{
+				certRef("storefront", "shop-tls"),
+				certRef("", "wildcard-tls"),
+				certRef("storefront", "shop-tls"),
+				certRef("admin", "admin-tls"),
+			},
+			want: []types.NamespacedName{
+				{Namespace: "storefront", Name: "shop-tls"},
+				{Namespace: "admin", Name: "admin-tls"},
+			},
+		},
+		{name: "not a secret", refs: []gatewayv1.SecretObjectReference{configMap}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gw := &gatewayv1.Gateway{Spec: gatewayv1.GatewaySpec{Listeners: []gatewayv1.Listener{
+				{Name: "http", Protocol: gatewayv1.HTTPProtocolType, Port: 80},
+				{Name: "https", Protocol: gatewayv1.HTTPSProtocolType, Port: 443, TLS: &gatewayv1.GatewayTLSConfig{CertificateRefs: tt.refs}},
+			}}}
+			if got := SecretsNeedingGrants(gw, "gateway"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SecretsNeedingGrants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeHTTPRoutes(t *testing.T) {
+	route := func(name, host, path string) *gatewayv1.HTTPRoute {
+		r := BuildWeightedRoute(name, "storefront", host, path, []WeightedBackend{{Service: name, Port: 8080, Weight: 1}})
+		r.Spec.ParentRefs = []gatewayv1.ParentReference{{Name: "public"}}
+		return r
+	}
+	exact := route("health", "shop.orcapod.io", "/healthz")
+	exactType := gatewayv1.PathMatchExact
+	exact.Spec.Rules[0].Matches[0].Path.Type = &exactType
+
+	otherParent := route("api", "shop.orcapod.io", "/api")
+	otherParent.Spec.ParentRefs = []gatewayv1.ParentReference{{Name: "internal"}}
+	otherNamespace := route("api", "shop.orcapod.io", "/api")
+	otherNamespace.Namespace = "other"
+
+	tests := []struct {
+		name      string
+		routes    []*gatewayv1.HTTPRoute
+		wantPaths []string
+		wantErr   string
+	}{
+		{
+			name:      "same host",
+			routes:    []*gatewayv1.HTTPRoute{route("web", "shop.orcapod.io", "/"), route("api", "shop.orcapod.io", "/api"), exact},
+			wantPaths: []string{"/healthz", "/api", "/"},
+		},
+		{name: "conflicting parentRefs", routes: []*gatewayv1.HTTPRoute{route("web", "shop.orcapod.io", "/"), otherParent}, wantErr: "conflicting parentRefs"},
+		{name: "different hostnames", routes: []*gatewayv1.HTTPRoute{route("web", "shop.orcapod.io", "/"), route("api", "api.orcapod.io", "/api")}, wantErr: "different hostnames"},
+		{name: "different namespaces", routes: []*gatewayv1.HTTPRoute{route("web", "shop.orcapod.io", "/"), otherNamespace}, wantErr: "different namespace"},
+		{name: "nothing to merge", wantErr: "no HTTPRoutes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := MergeHTTPRoutes(tt.routes)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("MergeHTTPRoutes() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergeHTTPRoutes() error = %v", err)
+			}
+			if merged.Name != "web" || !reflect.DeepEqual(merged.Spec.ParentRefs, tt.routes[0].Spec.ParentRefs) {
+				t.Errorf("merged route %s has parentRefs %v", merged.Name, merged.Spec.ParentRefs)
+			}
+			var paths []string
+			for _, rule := range merged.Spec.Rules {
+				paths = append(paths, *rule.Matches[0].Path.Value)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("rule paths = %v, want %v", paths, tt.wantPaths)
+			}
+			if violations := ValidateHTTPRoute(merged); len(violations) > 0 {
+				t.Errorf("ValidateHTTPRoute(merged) = %v", violations)
+			}
+		})
+	}
+}
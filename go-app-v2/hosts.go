@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SplitIngress splits an Ingress into one Ingress per host, named
+// "<name>-<host-slug>", so hosts can be migrated independently. Rules for the
+// same host stay together, and each split keeps only the TLS entries covering
+// its host.
+//
+// nginx annotations apply to the whole Ingress and cannot be attributed to a
+// single host, so every split carries a copy of all annotations and labels.
+// spec.defaultBackend is kept on the first split only, since more than one
+// catch-all backend would conflict.
+func SplitIngress(ingress *networkingv1.Ingress) []*networkingv1.Ingress {
+	var hosts []string
+	rulesByHost := make(map[string][]networkingv1.IngressRule)
+	for _, rule := range ingress.Spec.Rules {
+		if _, ok := rulesByHost[rule.Host]; !ok {
+			hosts = append(hosts, rule.Host)
+		}
+		rulesByHost[rule.Host] = append(rulesByHost[rule.Host], *rule.DeepCopy())
+	}
+
+	splits := make([]*networkingv1.Ingress, 0, len(hosts))
+	for i, host := range hosts {
+		split := &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        ingress.Name + "-" + hostSlug(host),
+				Namespace:   ingress.Namespace,
+				Labels:      maps.Clone(ingress.Labels),
+				Annotations: maps.Clone(ingress.Annotations),
+			},
+			Spec: *ingress.Spec.DeepCopy(),
+		}
+		split.Spec.Rules = rulesByHost[host]
+		split.Spec.TLS = tlsForHost(ingress.Spec.TLS, host)
+		if i > 0 {
+			split.Spec.DefaultBackend = nil
+		}
+		splits = append(splits, split)
+	}
+	return splits
+}
+
+// hostSlug turns a host into a name fragment, e.g. "shop.orcapod.io" becomes
+// "shop-orcapod-io". A wildcard host such as "*.orcapod.io" becomes
+// "wildcard-orcapod-io" so it can't collide with its parent domain, and the
+// empty host maps to "default".
+func hostSlug(host string) string {
+	if suffix, ok := strings.CutPrefix(host, "*."); ok {
+		return "wildcard-" + sanitizeDNSSubdomain(suffix)
+	}
+	if slug := sanitizeDNSSubdomain(host); slug != "" {
+		return slug
+	}
+	return "default"
+}
+
+// tlsForHost returns the TLS entries that cover host, either by listing it or
+// through a wildcard, narrowed to that host.
+func tlsForHost(entries []networkingv1.IngressTLS, host string) []networkingv1.IngressTLS {
+	var out []networkingv1.IngressTLS
+	for _, tls := range entries {
+		for _, h := range tls.Hosts {
+			if h == host || wildcardMatches(h, host) {
+				out = append(out, networkingv1.IngressTLS{Hosts: []string{host}, SecretName: tls.SecretName})
+				break
+			}
+		}
+	}
+	return out
+}
+
+// GroupIngressesByHost maps each host to the ingresses with a rule for it. An
+// ingress with several hosts appears under each of them; hostless rules are
+// grouped under "".
+func GroupIngressesByHost(ingresses []networkingv1.Ingress) map[string][]*networkingv1.Ingress {
+	groups := make(map[string][]*networkingv1.Ingress)
+	for i := range ingresses {
+		ing := &ingresses[i]
+		seen := make(map[string]bool)
+		for _, rule := range ing.Spec.Rules {
+			if seen[rule.Host] {
+				continue
+			}
+			seen[rule.Host] = true
+			groups[rule.Host] = append(groups[rule.Host], ing)
+		}
+	}
+	return groups
+}
+
+// DescribePerPathAnnotations reports, for each host served by more than one
+// ingress, the nginx annotations whose values differ between those ingresses.
+// Teams split ingresses this way to get per-path behavior out of per-ingress
+// annotations, so each difference has to become a per-rule HTTPRoute filter
+// rather than a route-wide setting. Output is sorted by host, then annotation.
+func DescribePerPathAnnotations(ingresses []networkingv1.Ingress) []string {
+	groups := GroupIngressesByHost(ingresses)
+	hosts := make([]string, 0, len(groups))
+	for host := range groups {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var out []string
+	for _, host := range hosts {
+		group := groups[host]
+		if len(group) < 2 {
+			continue
+		}
+
+		annotations := make([]map[string]string, len(group))
+		keySet := make(map[string]bool)
+		for i, ing := range group {
+			annotations[i] = GetNginxAnnotations(ing)
+			for k := range annotations[i] {
+				keySet[k] = true
+			}
+		}
+		keys := make([]string, 0, len(keySet))
+		for k := range keySet {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			values := make([]string, len(group))
+			var first string
+			differs := false
+			for i, ing := range group {
+				v := "<unset>"
+				if raw, ok := annotations[i][key]; ok {
+					v = fmt.Sprintf("%q", raw)
+				}
+				if i == 0 {
+					first = v
+				} else if v != first {
+					differs = true
+				}
+				values[i] = fmt.Sprintf("%s/%s=%s", ing.Namespace, ing.Name, v)
+			}
+			if differs {
+				out = append(out, fmt.Sprintf("host %q: %s differs: %s", host, key, strings.Join(values, ", ")))
+			}
+		}
+	}
+	return out
+}
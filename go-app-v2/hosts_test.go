@@ -0,0 +1,202 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// multiHostIngress returns an ingress with a rule for each host, sharing one
+// wildcard TLS entry and a default backend.
+func multiHostIngress(hosts ...string) *networkingv1.Ingress {
+	m := &IngressManager{}
+	ingress := m.BuildBasicIngress("storefront", "storefront", hosts[0], "/", "web-frontend", 8080)
+	for _, host := range hosts[1:] {
+		rule := *ingress.Spec.Rules[0].DeepCopy()
+		rule.Host = host
+		ingress.Spec.Rules = append(ingress.Spec.Rules, rule)
+	}
+	ingress.Labels = map[string]string{"team": "web"}
+	ingress.Annotations = map[string]string{"nginx.ingress.kubernetes.io/ssl-redirect": "true"}
+	ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: hosts[:2], SecretName: "shop-tls"}}
+	ingress.Spec.DefaultBackend = ingress.Spec.Rules[0].HTTP.Paths[0].Backend.DeepCopy()
+	return ingress
+}
+
+func TestSplitIngress(t *testing.T) {
+	ingress := multiHostIngress("shop.orcapod.io", "api.orcapod.io", "")
+	splits := SplitIngress(ingress)
+
+	tests := []struct {
+		name           string
+		host           string
+		wantTLS        []networkingv1.IngressTLS
+		wantDefBackend bool
+	}{
+		{
+			name:           "storefront-shop-orcapod-io",
+			host:           "shop.orcapod.io",
+			wantTLS:        []networkingv1.IngressTLS{{Hosts: []string{"shop.orcapod.io"}, SecretName: "shop-tls"}},
+			wantDefBackend: true,
+		},
+		{
+			name:    "storefront-api-orcapod-io",
+			host:    "api.orcapod.io",
+			wantTLS: []networkingv1.IngressTLS{{Hosts: []string{"api.orcapod.io"}, SecretName: "shop-tls"}},
+		},
+		{name: "storefront-default", host: ""},
+	}
+	if len(splits) != len(tests) {
+		t.Fatalf("got %d splits, want %d", len(splits), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			split := splits[i]
+			if split.Name != tt.name || split.Namespace != ingress.Namespace {
+				t.Errorf("split %d is %s/%s, want %s/%s", i, split.Namespace, split.Name, ingress.Namespace, tt.name)
+			}
+			if len(split.Spec.Rules) != 1 || split.Spec.Rules[0].Host != tt.host {
+				t.Errorf("rules = %+v, want one rule for %q", split.Spec.Rules, tt.host)
+			}
+			if !reflect.DeepEqual(split.Spec.TLS, tt.wantTLS) {
+				t.Errorf("TLS = %+v, want %+v", split.Spec.TLS, tt.wantTLS)
+			}
+			if (split.Spec.DefaultBackend != nil) != tt.wantDefBackend {
+				t.Errorf("has defaultBackend = %v, want %v", split.Spec.DefaultBackend != nil, tt.wantDefBackend)
+			}
+			if !reflect.DeepEqual(split.Labels, ingress.Labels) || !reflect.DeepEqual(split.Annotations, ingress.Annotations) {
+				t.Errorf("metadata = %v %v, want a copy of the source", split.Labels, split.Annotations)
+			}
+		})
+	}
+
+	t.Run("splits do not share metadata", func(t *testing.T) {
+		splits[0].Labels["team"] = "changed"
+		splits[0].Annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "false"
+		splits[0].Spec.Rules[0].HTTP.Paths[0].Path = "/changed"
+		if ingress.Labels["team"] != "web" || splits[1].Labels["team"] != "web" {
+			t.Error("mutating a split's labels changed the source or a sibling")
+		}
+		if ingress.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"] != "true" {
+			t.Error("mutating a split's annotations changed the source")
+		}
+		if ingress.Spec.Rules[0].HTTP.Paths[0].Path != "/" {
+			t.Error("mutating a split's rules changed the source")
+		}
+	})
+
+	t.Run("same host rules stay together", func(t *testing.T) {
+		ingress := multiHostIngress("shop.orcapod.io", "shop.orcapod.io")
+		ingress.ObjectMeta = metav1.ObjectMeta{Name: "storefront", Namespace: "storefront"}
+		splits := SplitIngress(ingress)
+		if len(splits) != 1 || len(splits[0].Spec.Rules) != 2 {
+			t.Errorf("got %d splits, want one with both rules", len(splits))
+		}
+	})
+
+	t.Run("wildcard TLS", func(t *testing.T) {
+		ingress := multiHostIngress("*.orcapod.io", "orcapod.io", "api.orcapod.io")
+		ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"*.orcapod.io"}, SecretName: "wildcard-tls"}}
+		splits := SplitIngress(ingress)
+
+		want := []struct {
+			name string
+			tls  []networkingv1.IngressTLS
+		}{
+			{name: "storefront-wildcard-orcapod-io", tls: []networkingv1.IngressTLS{{Hosts: []string{"*.orcapod.io"}, SecretName: "wildcard-tls"}}},
+			{name: "storefront-orcapod-io"},
+			{name: "storefront-api-orcapod-io", tls: []networkingv1.IngressTLS{{Hosts: []string{"api.orcapod.io"}, SecretName: "wildcard-tls"}}},
+		}
+		if len(splits) != len(want) {
+			t.Fatalf("got %d splits, want %d", len(splits), len(want))
+		}
+		for i, w := range want {
+			if splits[i].Name != w.name {
+				t.Errorf("split %d name = %q, want %q", i, splits[i].Name, w.name)
+			}
+			if !reflect.DeepEqual(splits[i].Spec.TLS, w.tls) {
+				t.Errorf("split %d TLS = %+v, want %+v", i, splits[i].Spec.TLS, w.tls)
+			}
+		}
+	})
+}
+
+func TestGroupIngressesByHost(t *testing.T) {
+	m := &IngressManager{}
+	web := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	api := m.BuildBasicIngress("api", "storefront", "shop.orcapod.io", "/api", "api-backend", 8080)
+	docs := m.BuildBasicIngress("docs", "docs", "docs.orcapod.io", "/", "docs", 8080)
+	docs.Spec.Rules = append(docs.Spec.Rules, *docs.Spec.Rules[0].DeepCopy())
+	ingresses := []networkingv1.Ingress{*web, *api, *docs}
+
+	groups := GroupIngressesByHost(ingresses)
+	got := make(map[string][]string)
+	for host, group := range groups {
+		for _, ing := range group {
+			got[host] = append(got[host], ing.Namespace+"/"+ing.Name)
+		}
+	}
+	want := map[string][]string{
+		"shop.orcapod.io": {"storefront/web", "storefront/api"},
+		"docs.orcapod.io": {"docs/docs"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupIngressesByHost() = %v, want %v", got, want)
+	}
+}
+
+func TestDescribePerPathAnnotations(t *testing.T) {
+	tests := []struct {
+		name      string
+		apiHost   string
+		webAnnot  map[string]string
+		apiAnnot  map[string]string
+		wantLines []string
+	}{
+		{
+			name:     "different hosts",
+			apiHost:  "api.orcapod.io",
+			webAnnot: map[string]string{"nginx.ingress.kubernetes.io/limit-rps": "50"},
+			apiAnnot: map[string]string{"nginx.ingress.kubernetes.io/limit-rps": "10"},
+		},
+		{
+			name:     "same host, same annotations",
+			apiHost:  "shop.orcapod.io",
+			webAnnot: map[string]string{"nginx.ingress.kubernetes.io/limit-rps": "50"},
+			apiAnnot: map[string]string{"nginx.ingress.kubernetes.io/limit-rps": "50"},
+		},
+		{
+			name:     "same host, differing annotations",
+			apiHost:  "shop.orcapod.io",
+			webAnnot: map[string]string{"nginx.ingress.kubernetes.io/limit-rps": "50"},
+			apiAnnot: map[string]string{
+				"nginx.ingress.kubernetes.io/limit-rps":          "10",
+				"nginx.ingress.kubernetes.io/proxy-read-timeout": "120",
+			},
+			wantLines: []string{
+				`host "shop.orcapod.io": nginx.ingress.kubernetes.io/limit-rps differs: storefront/web="50", storefront/api="10"`,
+				`host "shop.orcapod.io": nginx.ingress.kubernetes.io/proxy-read-timeout differs: storefront/web=<unset>, storefront/api="120"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			web := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+			api := m.BuildBasicIngress("api", "storefront", tt.apiHost, "/api", "api-backend", 8080)
+			for k, v := range tt.webAnnot {
+				web.Annotations[k] = v
+			}
+			for k, v := range tt.apiAnnot {
+				api.Annotations[k] = v
+			}
+
+			got := DescribePerPathAnnotations([]networkingv1.Ingress{*web, *api})
+			if !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("DescribePerPathAnnotations() = %q, want %q", got, tt.wantLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+// kustomization is the subset of a kustomization.yaml that WriteKustomization
+// generates.
+type kustomization struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Resources  []string `json:"resources"`
+}
+
+// WriteKustomization writes each object in a MigrationResult to its own
+// <kind>-<name>.yaml file in dir, plus a kustomization.yaml listing them as
+// resources, so the output can be used directly as a kustomize base. dir is
+// created if needed.
+func WriteKustomization(dir string, result *MigrationResult) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	var resources []string
+	write := func(obj runtime.Object, gvk schema.GroupVersionKind, name string) error {
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		file := strings.ToLower(gvk.Kind) + "-" + name + ".yaml"
+		for _, existing := range resources {
+			if existing == file {
+				return fmt.Errorf("two %s objects named %q would both be written to %s", gvk.Kind, name, file)
+			}
+		}
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s %s: %w", gvk.Kind, name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		resources = append(resources, file)
+		return nil
+	}
+
+	if result.Gateway != nil {
+		gw := result.Gateway.DeepCopy()
+		if err := write(gw, gatewayv1.SchemeGroupVersion.WithKind("Gateway"), gw.Name); err != nil {
+			return err
+		}
+	}
+	for _, g := range result.ReferenceGrants {
+		grant := g.DeepCopy()
+		if err := write(grant, gatewayv1beta1.SchemeGroupVersion.WithKind("ReferenceGrant"), grant.Name); err != nil {
+			return err
+		}
+	}
+	for _, r := range result.HTTPRoutes {
+		route := r.DeepCopy()
+		if err := write(route, gatewayv1.SchemeGroupVersion.WithKind("HTTPRoute"), route.Name); err != nil {
+			return err
+		}
+	}
+
+	data, err := yaml.Marshal(kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  resources,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal kustomization: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "kustomization.yaml"), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write kustomization.yaml: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestWriteKustomization(t *testing.T) {
+	t.Run("writes one file per object", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "base")
+		if err := WriteKustomization(dir, testMigrationResult()); err != nil {
+			t.Fatalf("WriteKustomization() error = %v", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var k kustomization
+		if err := yaml.Unmarshal(data, &k); err != nil {
+			t.Fatal(err)
+		}
+		wantResources := []string{
+			"gateway-public.yaml",
+			"referencegrant-certs.yaml",
+			"httproute-storefront.yaml",
+			"httproute-storefront-api.yaml",
+		}
+		if k.Kind != "Kustomization" || !reflect.DeepEqual(k.Resources, wantResources) {
+			t.Errorf("kustomization = %+v, want resources %v", k, wantResources)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var files []string
+		for _, e := range entries {
+			files = append(files, e.Name())
+		}
+		want := append([]string{"kustomization.yaml"}, wantResources...)
+		sort.Strings(want)
+		if !reflect.DeepEqual(files, want) {
+			t.Errorf("files = %v, want %v", files, want)
+		}
+
+		data, err = os.ReadFile(filepath.Join(dir, "httproute-storefront.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var route gatewayv1.HTTPRoute
+		if err := yaml.Unmarshal(data, &route); err != nil {
+			t.Fatal(err)
+		}
+		if route.Kind != "HTTPRoute" || route.APIVersion != gatewayv1.GroupVersion.String() || route.Name != "storefront" {
+			t.Errorf("route file has %s %s %q", route.APIVersion, route.Kind, route.Name)
+		}
+	})
+
+	t.Run("duplicate names", func(t *testing.T) {
+		result := testMigrationResult()
+		result.HTTPRoutes = append(result.HTTPRoutes, &gatewayv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "storefront", Namespace: "other"},
+		})
+		if err := WriteKustomization(t.TempDir(), result); err == nil {
+			t.Error("WriteKustomization() accepted two HTTPRoutes writing the same file")
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// sourceRangeAnnotations are the nginx annotations that restrict client
+// source addresses.
+var sourceRangeAnnotations = []string{
+	"nginx.ingress.kubernetes.io/whitelist-source-range",
+	"nginx.ingress.kubernetes.io/allowlist-source-range",
+}
+
+// nonPublicPrefixes are address blocks that are not reachable from the public
+// internet: RFC 1918, RFC 4193 unique local, loopback and link-local.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("fe80::/10"),
+}
+
+// LintSourceRanges warns about source-range allowlists that don't restrict
+// much: ranges of 0.0.0.0/0 or ::/0, and ranges that include public address
+// space. Private ranges such as 10.0.0.0/8 produce no warning.
+func LintSourceRanges(ingress *networkingv1.Ingress) []string {
+	var warnings []string
+	for _, key := range sourceRangeAnnotations {
+		value, ok := ingress.Annotations[key]
+		if !ok {
+			continue
+		}
+		for _, raw := range strings.Split(value, ",") {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				continue
+			}
+			prefix, err := parseSourceRange(raw)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: invalid range %q", key, raw))
+				continue
+			}
+			switch {
+			case prefix.Bits() == 0:
+				warnings = append(warnings, fmt.Sprintf("%s: range %s allows every address", key, raw))
+			case !isNonPublicPrefix(prefix):
+				warnings = append(warnings, fmt.Sprintf("%s: range %s includes public address space", key, raw))
+			}
+		}
+	}
+	return warnings
+}
+
+// parseSourceRange parses a CIDR or a bare IP address, which nginx treats as a
+// single-host range.
+func parseSourceRange(s string) (netip.Prefix, error) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
+
+// isNonPublicPrefix reports whether prefix lies entirely within one of the
+// nonPublicPrefixes.
+func isNonPublicPrefix(prefix netip.Prefix) bool {
+	for _, block := range nonPublicPrefixes {
+		if block.Bits() <= prefix.Bits() && block.Contains(prefix.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultTimeoutLintThreshold is the proxy timeout above which LintTimeouts
+// warns when no threshold is given.
+const DefaultTimeoutLintThreshold = 600 * time.Second
+
+// proxyTimeoutAnnotations are the nginx proxy timeout annotations, whose values
+// are in seconds.
+var proxyTimeoutAnnotations = []string{
+	"nginx.ingress.kubernetes.io/proxy-connect-timeout",
+	"nginx.ingress.kubernetes.io/proxy-read-timeout",
+	"nginx.ingress.kubernetes.io/proxy-send-timeout",
+}
+
+// LintTimeouts warns about proxy timeouts longer than threshold, or
+// DefaultTimeoutLintThreshold if threshold is zero or less. Long timeouts hold
+// upstream connections open and usually mean a websocket endpoint is being
+// kept alive by timeout rather than handled explicitly. Values that are not
+// integers are left to AdmissionCheck.
+func LintTimeouts(ingress *networkingv1.Ingress, threshold time.Duration) []string {
+	if threshold <= 0 {
+		threshold = DefaultTimeoutLintThreshold
+	}
+	var warnings []string
+	for _, key := range proxyTimeoutAnnotations {
+		value, ok := ingress.Annotations[key]
+		if !ok {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		if time.Duration(seconds)*time.Second > threshold {
+			warnings = append(warnings, fmt.Sprintf("%s: %ds exceeds %ds; use explicit websocket handling instead of a long timeout",
+				key, seconds, int(threshold.Seconds())))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// annotatedIngress returns an otherwise empty ingress carrying annotations.
+func annotatedIngress(annotations map[string]string) *networkingv1.Ingress {
+	return &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "storefront", Annotations: annotations}}
+}
+
+func TestLintSourceRanges(t *testing.T) {
+	tests := []struct {
+		name         string
+		ranges       string
+		wantWarnings int
+	}{
+		{name: "everything", ranges: "0.0.0.0/0", wantWarnings: 1},
+		{name: "everything v6", ranges: "::/0", wantWarnings: 1},
+		{name: "private", ranges: "10.0.0.0/8"},
+		{name: "private list", ranges: "10.0.0.0/8, 192.168.1.0/24,172.16.0.0/12"},
+		{name: "private single host", ranges: "10.1.2.3"},
+		{name: "public block", ranges: "10.0.0.0/8,203.0.113.0/24", wantWarnings: 1},
+		{name: "wider than private", ranges: "10.0.0.0/7", wantWarnings: 1},
+		{name: "invalid", ranges: "10.0.0.0/33", wantWarnings: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range sourceRangeAnnotations {
+				ingress := annotatedIngress(map[string]string{key: tt.ranges})
+				if got := LintSourceRanges(ingress); len(got) != tt.wantWarnings {
+					t.Errorf("LintSourceRanges(%s=%q) = %v, want %d warnings", key, tt.ranges, got, tt.wantWarnings)
+				}
+			}
+		})
+	}
+}
+
+func TestLintTimeouts(t *testing.T) {
+	tests := []struct {
+		name         string
+		annotations  map[string]string
+		threshold    time.Duration
+		wantWarnings int
+	}{
+		{name: "one hour", annotations: map[string]string{"nginx.ingress.kubernetes.io/proxy-read-timeout": "3600"}, wantWarnings: 1},
+		{name: "two minutes", annotations: map[string]string{"nginx.ingress.kubernetes.io/proxy-read-timeout": "120"}},
+		{name: "at default threshold", annotations: map[string]string{"nginx.ingress.kubernetes.io/proxy-send-timeout": "600"}},
+		{
+			name: "all three long",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/proxy-connect-timeout": "900",
+				"nginx.ingress.kubernetes.io/proxy-read-timeout":    "3600",
+				"nginx.ingress.kubernetes.io/proxy-send-timeout":    "3600",
+			},
+			wantWarnings: 3,
+		},
+		{
+			name:         "custom threshold",
+			annotations:  map[string]string{"nginx.ingress.kubernetes.io/proxy-read-timeout": "120"},
+			threshold:    time.Minute,
+			wantWarnings: 1,
+		},
+		{name: "not an integer", annotations: map[string]string{"nginx.ingress.kubernetes.io/proxy-read-timeout": "1h"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LintTimeouts(annotatedIngress(tt.annotations), tt.threshold); len(got) != tt.wantWarnings {
+				t.Errorf("LintTimeouts() = %v, want %d warnings", got, tt.wantWarnings)
+			}
+		})
+	}
+}
@@ -8,21 +8,39 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"maps"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	gatewayclientset "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
 )
 
 func main() {
+	dryRun := flag.Bool("dry-run", false, "print the ingresses that would be created without changing the cluster")
+	yes := flag.Bool("yes", false, "skip the confirmation prompt before creating resources")
+	flag.Parse()
+
 	kubeconfig := filepath.Join(os.Getenv("HOME"), ".kube", "config")
 	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
 	if err != nil {
@@ -37,15 +55,25 @@ func main() {
 	ctx := context.Background()
 	manager := NewIngressManager(clientset)
 
+	if !*dryRun && !*yes && isTerminal(os.Stdin) && !confirm("Create storefront ingresses in the cluster?") {
+		fmt.Println("Aborted.")
+		return
+	}
+
 	// Ensure the nginx IngressClass exists before provisioning
-	if err := manager.EnsureIngressClass(ctx); err != nil {
+	if *dryRun {
+		fmt.Println("Dry run: skipping IngressClass check")
+	} else if err := manager.EnsureIngressClass(ctx); err != nil {
 		log.Fatalf("Failed to ensure IngressClass: %v", err)
 	}
 
 	// Provision the storefront tenant — public-facing web app with API backend
-	if err := provisionStorefront(ctx, manager); err != nil {
+	if err := provisionStorefront(ctx, manager, *dryRun); err != nil {
 		log.Fatalf("Failed to provision storefront: %v", err)
 	}
+	if *dryRun {
+		return
+	}
 
 	// List all provisioned ingresses for verification
 	ingresses, err := manager.ListIngresses(ctx, "storefront")
@@ -58,10 +86,33 @@ func main() {
 	}
 }
 
+// isTerminal reports whether f is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// confirm asks a yes/no question on stdin and reports whether the answer was yes.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
 // provisionStorefront creates the ingress resources for the public storefront.
 // The storefront has a web frontend and API backend — both behind TLS with
-// standard security headers.
-func provisionStorefront(ctx context.Context, m *IngressManager) error {
+// standard security headers. With dryRun set the ingresses are submitted as
+// server-side dry runs and nothing is persisted.
+func provisionStorefront(ctx context.Context, m *IngressManager, dryRun bool) error {
+	create, verb := m.CreateIngress, "Created"
+	if dryRun {
+		create, verb = m.CreateIngressDryRun, "Would create"
+	}
+
 	ingress := m.BuildBasicIngress("storefront", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
 
 	// TLS termination at the ingress
@@ -82,15 +133,15 @@ func provisionStorefront(ctx context.Context, m *IngressManager) error {
 	// HSTS
 	m.SetHSTS(ingress, 31536000, true)
 
-	if err := ValidateIngress(ingress); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+	if err := m.validateForProvision(ingress); err != nil {
+		return err
 	}
 
-	created, err := m.CreateIngress(ctx, ingress)
+	created, err := create(ctx, ingress)
 	if err != nil {
 		return fmt.Errorf("failed to create storefront ingress: %w", err)
 	}
-	fmt.Printf("Created storefront ingress: %s\n", created.Name)
+	fmt.Printf("%s storefront ingress: %s\n", verb, created.Name)
 
 	// Separate ingress for the API with custom timeouts
 	apiIngress := m.BuildBasicIngress("storefront-api", "storefront", "api.orcapod.io", "/", "api-backend", 8080)
@@ -104,12 +155,30 @@ func provisionStorefront(ctx context.Context, m *IngressManager) error {
 		},
 	}
 
-	created, err = m.CreateIngress(ctx, apiIngress)
+	if err := m.validateForProvision(apiIngress); err != nil {
+		return err
+	}
+
+	created, err = create(ctx, apiIngress)
 	if err != nil {
 		return fmt.Errorf("failed to create API ingress: %w", err)
 	}
-	fmt.Printf("Created API ingress: %s\n", created.Name)
+	fmt.Printf("%s API ingress: %s\n", verb, created.Name)
+
+	return nil
+}
 
+// validateForProvision runs ValidateIngress on an ingress about to be
+// provisioned, recording a ValidationFailed event if it fails, and logs its
+// non-fatal IngressWarnings otherwise.
+func (m *IngressManager) validateForProvision(ingress *networkingv1.Ingress) error {
+	if err := ValidateIngress(ingress); err != nil {
+		m.recordEvent(ingressRef(ingress.Namespace, ingress.Name), corev1.EventTypeWarning, ReasonValidationFailed, "Validation failed: %v", err)
+		return fmt.Errorf("validation failed for ingress %s: %w", ingress.Name, err)
+	}
+	for _, w := range IngressWarnings(ingress) {
+		log.Printf("Warning: ingress %s/%s: %s", ingress.Namespace, ingress.Name, w)
+	}
 	return nil
 }
 
@@ -124,8 +193,16 @@ func getIngressClassName(ingress *networkingv1.Ingress) string {
 }
 
 // IngressManager handles CRUD operations for Kubernetes Ingress resources.
+// When built with NewIngressManagerWithGateway it can also manage Gateway API
+// resources.
 type IngressManager struct {
 	clientset kubernetes.Interface
+	gwclient  gatewayclientset.Interface
+	metrics   *provisionerMetrics
+	recorder  record.EventRecorder
+
+	gatewayProgrammedTimeout time.Duration
+	recordCopyProvenance     bool
 }
 
 // NewIngressManager creates a new IngressManager.
@@ -135,35 +212,414 @@ func NewIngressManager(clientset kubernetes.Interface) *IngressManager {
 
 // CreateIngress creates a new Ingress resource in the cluster.
 func (m *IngressManager) CreateIngress(ctx context.Context, ingress *networkingv1.Ingress) (*networkingv1.Ingress, error) {
-	return m.clientset.NetworkingV1().Ingresses(ingress.Namespace).Create(ctx, ingress, metav1.CreateOptions{})
+	start := m.metrics.start()
+	created, err := m.clientset.NetworkingV1().Ingresses(ingress.Namespace).Create(ctx, ingress, metav1.CreateOptions{})
+	m.metrics.observe("create", start)
+	if err == nil {
+		m.metrics.count("created")
+	}
+	m.recordResult(created, ingress.Namespace, ingress.Name, err, ReasonCreated, ReasonCreateFailed, "create")
+	return created, err
+}
+
+// CreateIngressDryRun submits an Ingress create as a server-side dry run. The
+// API server applies defaulting and validation and returns the result, but
+// nothing is persisted.
+func (m *IngressManager) CreateIngressDryRun(ctx context.Context, ingress *networkingv1.Ingress) (*networkingv1.Ingress, error) {
+	return m.clientset.NetworkingV1().Ingresses(ingress.Namespace).Create(ctx, ingress, metav1.CreateOptions{
+		DryRun: []string{metav1.DryRunAll},
+	})
 }
 
 // UpdateIngress updates an existing Ingress resource.
 func (m *IngressManager) UpdateIngress(ctx context.Context, ingress *networkingv1.Ingress) (*networkingv1.Ingress, error) {
-	return m.clientset.NetworkingV1().Ingresses(ingress.Namespace).Update(ctx, ingress, metav1.UpdateOptions{})
+	start := m.metrics.start()
+	updated, err := m.clientset.NetworkingV1().Ingresses(ingress.Namespace).Update(ctx, ingress, metav1.UpdateOptions{})
+	m.metrics.observe("update", start)
+	if err == nil {
+		m.metrics.count("updated")
+	}
+	m.recordResult(updated, ingress.Namespace, ingress.Name, err, ReasonUpdated, ReasonUpdateFailed, "update")
+	return updated, err
+}
+
+// UpdateIngressDryRun submits an Ingress update as a server-side dry run, to
+// preview the defaulted and validated result of a change without persisting
+// it.
+func (m *IngressManager) UpdateIngressDryRun(ctx context.Context, ingress *networkingv1.Ingress) (*networkingv1.Ingress, error) {
+	return m.clientset.NetworkingV1().Ingresses(ingress.Namespace).Update(ctx, ingress, metav1.UpdateOptions{
+		DryRun: []string{metav1.DryRunAll},
+	})
+}
+
+// PatchAnnotation sets a single annotation on an Ingress with a JSON merge
+// patch, leaving the rest of the object untouched. An empty value removes the
+// annotation.
+func (m *IngressManager) PatchAnnotation(ctx context.Context, namespace, name, key, value string) error {
+	var v any
+	if value != "" {
+		v = value
+	}
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]any{key: v},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.clientset.NetworkingV1().Ingresses(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to patch annotation %s on ingress %s/%s: %w", key, namespace, name, err)
+	}
+	return nil
 }
 
 // DeleteIngress deletes an Ingress resource by name and namespace.
 func (m *IngressManager) DeleteIngress(ctx context.Context, namespace, name string) error {
-	return m.clientset.NetworkingV1().Ingresses(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	start := m.metrics.start()
+	err := m.clientset.NetworkingV1().Ingresses(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	m.metrics.observe("delete", start)
+	if err == nil {
+		m.metrics.count("deleted")
+	}
+	m.recordResult(ingressRef(namespace, name), namespace, name, err, ReasonDeleted, ReasonDeleteFailed, "delete")
+	return err
+}
+
+// DeleteIngressSafe deletes an Ingress unless it is the only ingress, across
+// all namespaces, serving one of its hosts. In that case it refuses and lists
+// the hosts that would lose all ingresses. force skips the check.
+func (m *IngressManager) DeleteIngressSafe(ctx context.Context, namespace, name string, force bool) error {
+	if !force {
+		target, err := m.GetIngress(ctx, namespace, name)
+		if err != nil {
+			return err
+		}
+		ingresses, err := m.ListIngresses(ctx, metav1.NamespaceAll)
+		if err != nil {
+			return fmt.Errorf("failed to list ingresses: %w", err)
+		}
+
+		served := make(map[string]bool)
+		for _, ing := range ingresses {
+			if ing.Namespace == namespace && ing.Name == name {
+				continue
+			}
+			for _, rule := range ing.Spec.Rules {
+				served[rule.Host] = true
+			}
+		}
+
+		var orphaned []string
+		for _, rule := range target.Spec.Rules {
+			if rule.Host == "" || served[rule.Host] {
+				continue
+			}
+			served[rule.Host] = true
+			orphaned = append(orphaned, rule.Host)
+		}
+		if len(orphaned) > 0 {
+			return fmt.Errorf("refusing to delete ingress %s/%s: it is the last ingress serving %s",
+				namespace, name, strings.Join(orphaned, ", "))
+		}
+	}
+	return m.DeleteIngress(ctx, namespace, name)
 }
 
 // GetIngress retrieves a specific Ingress by name.
 func (m *IngressManager) GetIngress(ctx context.Context, namespace, name string) (*networkingv1.Ingress, error) {
-	return m.clientset.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
+	start := m.metrics.start()
+	ingress, err := m.clientset.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
+	m.metrics.observe("get", start)
+	return ingress, err
 }
 
 // ListIngresses lists all Ingress resources in a namespace.
 func (m *IngressManager) ListIngresses(ctx context.Context, namespace string) ([]networkingv1.Ingress, error) {
+	start := m.metrics.start()
 	list, err := m.clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	m.metrics.observe("list", start)
 	if err != nil {
 		return nil, err
 	}
 	return list.Items, nil
 }
 
+// ingressWatchBackoff spaces out WatchIngresses reconnects, so a server that
+// keeps closing the watch or failing it with transient errors is not retried
+// in a tight loop. It is reset whenever a watch makes progress.
+var ingressWatchBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    6,
+	Cap:      30 * time.Second,
+}
+
+// WatchIngresses watches Ingresses in namespace (metav1.NamespaceAll for every
+// namespace) and calls handler for each add, update and delete until ctx is
+// cancelled, when it returns ctx.Err(). Existing ingresses are delivered as
+// adds first. The watch is re-established from the last seen resourceVersion
+// when the server closes it, and from scratch if that version has expired, in
+// which case current ingresses are delivered as adds again. Reconnects, and
+// retries after transient errors starting the watch, wait according to
+// ingressWatchBackoff.
+func (m *IngressManager) WatchIngresses(ctx context.Context, namespace string, handler func(eventType watch.EventType, ing *networkingv1.Ingress)) error {
+	resourceVersion := ""
+	backoff := ingressWatchBackoff
+	for {
+		w, err := m.clientset.NetworkingV1().Ingresses(namespace).Watch(ctx, metav1.ListOptions{
+			ResourceVersion:     resourceVersion,
+			AllowWatchBookmarks: true,
+		})
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil && isTransientError(err):
+			// retried after the backoff below
+		case err != nil:
+			return fmt.Errorf("failed to watch ingresses: %w", err)
+		default:
+			next, err := dispatchIngressEvents(ctx, w, resourceVersion, handler)
+			w.Stop()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				return err
+			}
+			if next != resourceVersion {
+				backoff = ingressWatchBackoff
+			}
+			resourceVersion = next
+		}
+
+		timer := time.NewTimer(backoff.Step())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatchIngressEvents feeds events from w to handler until the watch closes
+// or ctx is cancelled, returning the resourceVersion to resume from.
+func dispatchIngressEvents(ctx context.Context, w watch.Interface, resourceVersion string, handler func(watch.EventType, *networkingv1.Ingress)) (string, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion, nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return resourceVersion, nil
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified, watch.Deleted:
+				ing, ok := event.Object.(*networkingv1.Ingress)
+				if !ok {
+					continue
+				}
+				resourceVersion = ing.ResourceVersion
+				handler(event.Type, ing)
+			case watch.Bookmark:
+				if ing, ok := event.Object.(*networkingv1.Ingress); ok {
+					resourceVersion = ing.ResourceVersion
+				}
+			case watch.Error:
+				err := apierrors.FromObject(event.Object)
+				if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+					return "", nil
+				}
+				return resourceVersion, fmt.Errorf("ingress watch failed: %w", err)
+			}
+		}
+	}
+}
+
+// FilterIngresses returns the ingresses whose annotations satisfy pred.
+func FilterIngresses(ingresses []networkingv1.Ingress, pred func(map[string]string) bool) []networkingv1.Ingress {
+	var out []networkingv1.Ingress
+	for _, ing := range ingresses {
+		if pred(ing.Annotations) {
+			out = append(out, ing)
+		}
+	}
+	return out
+}
+
+// WithAnnotation returns a FilterIngresses predicate matching ingresses where
+// key is set to value. An empty value matches any value of key.
+func WithAnnotation(key, value string) func(map[string]string) bool {
+	return func(annotations map[string]string) bool {
+		v, ok := annotations[key]
+		return ok && (value == "" || v == value)
+	}
+}
+
+// CopyIngress clones an Ingress into another namespace, e.g. for blue/green
+// namespace migration. Server-populated metadata and status are dropped, and
+// rename, if non-nil, maps the source name to the new one. TLS secrets that
+// don't exist in the destination namespace don't stop the copy but are
+// returned as warnings, since the copy will not serve TLS until they are
+// created. After SetRecordCopyProvenance(true), the copy is annotated with the
+// source's UID and creation time.
+func (m *IngressManager) CopyIngress(ctx context.Context, srcNamespace, name, dstNamespace string, rename func(string) string) (*networkingv1.Ingress, []string, error) {
+	src, err := m.GetIngress(ctx, srcNamespace, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get source ingress: %w", err)
+	}
+
+	dstName := src.Name
+	if rename != nil {
+		dstName = rename(src.Name)
+	}
+	dst := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        dstName,
+			Namespace:   dstNamespace,
+			Labels:      maps.Clone(src.Labels),
+			Annotations: maps.Clone(src.Annotations),
+		},
+		Spec: *src.Spec.DeepCopy(),
+	}
+	if m.recordCopyProvenance {
+		RecordProvenance(dst, src)
+	}
+
+	missing, err := m.MissingTLSSecrets(ctx, dst)
+	if err != nil {
+		return nil, nil, err
+	}
+	var warnings []string
+	for _, secret := range missing {
+		warnings = append(warnings, fmt.Sprintf("TLS secret %s referenced by ingress %s does not exist in namespace %s", secret, dstName, dstNamespace))
+	}
+
+	created, err := m.CreateIngress(ctx, dst)
+	if err != nil {
+		return nil, warnings, err
+	}
+	return created, warnings, nil
+}
+
+// MissingTLSSecrets returns the TLS secrets referenced by the Ingress that
+// don't exist in its namespace, so ingresses aren't provisioned pointing at
+// missing certificates.
+func (m *IngressManager) MissingTLSSecrets(ctx context.Context, ingress *networkingv1.Ingress) ([]string, error) {
+	var missing []string
+	checked := make(map[string]bool)
+	for _, tls := range ingress.Spec.TLS {
+		if tls.SecretName == "" || checked[tls.SecretName] {
+			continue
+		}
+		checked[tls.SecretName] = true
+
+		_, err := m.clientset.CoreV1().Secrets(ingress.Namespace).Get(ctx, tls.SecretName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			missing = append(missing, tls.SecretName)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to check TLS secret %s: %w", tls.SecretName, err)
+		}
+	}
+	return missing, nil
+}
+
+// WaitForIngressAddress blocks until the Ingress status reports a load
+// balancer address of either kind and returns it. Use a context deadline to
+// bound the wait.
+func (m *IngressManager) WaitForIngressAddress(ctx context.Context, namespace, name string) (string, error) {
+	return m.waitForIngressAddress(ctx, namespace, name, func(lb networkingv1.IngressLoadBalancerIngress) string {
+		if lb.IP != "" {
+			return lb.IP
+		}
+		return lb.Hostname
+	})
+}
+
+// WaitForIngressIP blocks until the Ingress status reports a load balancer IP,
+// as on GCP. Hostname-only status keeps polling.
+func (m *IngressManager) WaitForIngressIP(ctx context.Context, namespace, name string) (string, error) {
+	return m.waitForIngressAddress(ctx, namespace, name, func(lb networkingv1.IngressLoadBalancerIngress) string {
+		return lb.IP
+	})
+}
+
+// WaitForIngressHostname blocks until the Ingress status reports a load
+// balancer hostname, as on AWS. IP-only status keeps polling.
+func (m *IngressManager) WaitForIngressHostname(ctx context.Context, namespace, name string) (string, error) {
+	return m.waitForIngressAddress(ctx, namespace, name, func(lb networkingv1.IngressLoadBalancerIngress) string {
+		return lb.Hostname
+	})
+}
+
+// waitForIngressAddress polls the Ingress until pick returns a non-empty
+// address for one of its load balancer entries. Get errors are handled by
+// stopPolling.
+func (m *IngressManager) waitForIngressAddress(ctx context.Context, namespace, name string, pick func(networkingv1.IngressLoadBalancerIngress) string) (string, error) {
+	var address string
+	err := wait.PollUntilContextCancel(ctx, 2*time.Second, true, func(ctx context.Context) (bool, error) {
+		ingress, err := m.GetIngress(ctx, namespace, name)
+		if err != nil {
+			return false, stopPolling(err)
+		}
+		for _, lb := range ingress.Status.LoadBalancer.Ingress {
+			if address = pick(lb); address != "" {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed waiting for ingress %s/%s address: %w", namespace, name, err)
+	}
+	return address, nil
+}
+
+// stopPolling turns a Get error inside a poll loop into the error that ends
+// the poll. NotFound (the object may not be created yet) and transient API
+// errors return nil so the next tick tries again; anything else, such as
+// Forbidden, is returned and stops the wait.
+func stopPolling(err error) error {
+	if apierrors.IsNotFound(err) || isTransientError(err) {
+		return nil
+	}
+	return err
+}
+
 // BuildBasicIngress creates an Ingress object with a single host and path rule.
 func (m *IngressManager) BuildBasicIngress(name, namespace, host, path, serviceName string, servicePort int32) *networkingv1.Ingress {
+	return m.buildIngress(name, namespace, host, path, serviceName, networkingv1.ServiceBackendPort{Number: servicePort})
+}
+
+// BuildBasicIngressNamedPort creates an Ingress object with a single host and
+// path rule whose backend references the service port by name (e.g. "http").
+func (m *IngressManager) BuildBasicIngressNamedPort(name, namespace, host, path, serviceName, portName string) *networkingv1.Ingress {
+	return m.buildIngress(name, namespace, host, path, serviceName, networkingv1.ServiceBackendPort{Name: portName})
+}
+
+// RedirectPlaceholderService is the backend BuildRedirectIngress points at.
+// nginx answers with the redirect before proxying, so the Service never
+// receives traffic and need not exist, but an Ingress path must name one.
+const RedirectPlaceholderService = "redirect-placeholder"
+
+// BuildRedirectIngress creates an Ingress that only redirects host to
+// targetURL, e.g. apex to www, using the nginx permanent-redirect (301) or
+// temporal-redirect (302) annotation. Its single "/" path targets
+// RedirectPlaceholderService.
+func (m *IngressManager) BuildRedirectIngress(name, namespace, host, targetURL string, permanent bool) *networkingv1.Ingress {
+	ingress := m.BuildBasicIngress(name, namespace, host, "/", RedirectPlaceholderService, 80)
+	if permanent {
+		ingress.Annotations["nginx.ingress.kubernetes.io/permanent-redirect"] = targetURL
+	} else {
+		ingress.Annotations["nginx.ingress.kubernetes.io/temporal-redirect"] = targetURL
+	}
+	return ingress
+}
+
+func (m *IngressManager) buildIngress(name, namespace, host, path, serviceName string, port networkingv1.ServiceBackendPort) *networkingv1.Ingress {
 	nginxClass := "nginx"
 	pathType := networkingv1.PathTypePrefix
 
@@ -189,9 +645,7 @@ func (m *IngressManager) BuildBasicIngress(name, namespace, host, path, serviceN
 									Backend: networkingv1.IngressBackend{
 										Service: &networkingv1.IngressServiceBackend{
 											Name: serviceName,
-											Port: networkingv1.ServiceBackendPort{
-												Number: servicePort,
-											},
+											Port: port,
 										},
 									},
 								},
@@ -204,37 +658,143 @@ func (m *IngressManager) BuildBasicIngress(name, namespace, host, path, serviceN
 	}
 }
 
-// CreateIngressClass creates a new IngressClass for nginx.
-func (m *IngressManager) CreateIngressClass(ctx context.Context, name string) (*networkingv1.IngressClass, error) {
+// CreateIngressClass creates a new IngressClass for nginx. When isDefault is
+// true the class is marked as the cluster default, which claims every Ingress
+// that doesn't name a class.
+func (m *IngressManager) CreateIngressClass(ctx context.Context, name string, isDefault bool) (*networkingv1.IngressClass, error) {
 	ingressClass := &networkingv1.IngressClass{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
-			Annotations: map[string]string{
-				"ingressclass.kubernetes.io/is-default-class": "true",
-			},
 		},
 		Spec: networkingv1.IngressClassSpec{
 			Controller: "k8s.io/ingress-nginx",
 		},
 	}
+	if isDefault {
+		ingressClass.Annotations = map[string]string{
+			"ingressclass.kubernetes.io/is-default-class": "true",
+		}
+	}
 	return m.clientset.NetworkingV1().IngressClasses().Create(ctx, ingressClass, metav1.CreateOptions{})
 }
 
-// EnsureIngressClass checks if the nginx IngressClass exists and creates it if not.
+// EnsureIngressClass checks if the nginx IngressClass exists and creates it if
+// not. A class it creates is not the cluster default, so it won't claim other
+// teams' class-less ingresses in a shared cluster.
 func (m *IngressManager) EnsureIngressClass(ctx context.Context) error {
 	_, err := m.clientset.NetworkingV1().IngressClasses().Get(ctx, "nginx", metav1.GetOptions{})
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
 			return fmt.Errorf("failed to check IngressClass: %w", err)
 		}
-		_, err = m.CreateIngressClass(ctx, "nginx")
-		if err != nil {
+		_, err = m.CreateIngressClass(ctx, "nginx", false)
+		// Another process may have created the class since our Get.
+		if err != nil && !apierrors.IsAlreadyExists(err) {
 			return fmt.Errorf("failed to create IngressClass: %w", err)
 		}
 	}
 	return nil
 }
 
+// NormalizeIngressClass sets spec.ingressClassName to className on every
+// Ingress in the namespace that lacks it, and drops the deprecated
+// kubernetes.io/ingress.class annotation where it matches. Ingresses whose
+// annotation names a different class are left alone, as are ingresses that
+// are already normalized. It returns the number of ingresses updated.
+func (m *IngressManager) NormalizeIngressClass(ctx context.Context, namespace, className string) (int, error) {
+	ingresses, err := m.ListIngresses(ctx, namespace)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	changed := 0
+	for i := range ingresses {
+		ing := &ingresses[i]
+		annotation, hasAnnotation := ing.Annotations["kubernetes.io/ingress.class"]
+		if hasAnnotation && annotation != className {
+			continue
+		}
+
+		updated := false
+		if ing.Spec.IngressClassName == nil {
+			class := className
+			ing.Spec.IngressClassName = &class
+			updated = true
+		}
+		if *ing.Spec.IngressClassName == className && RemoveDeprecatedClassAnnotation(ing) {
+			updated = true
+		}
+		if !updated {
+			continue
+		}
+
+		if _, err := m.UpdateIngress(ctx, ing); err != nil {
+			return changed, fmt.Errorf("failed to update ingress %s: %w", ing.Name, err)
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// IngressesReferencingClass lists every Ingress, across all namespaces, that
+// references className via spec.ingressClassName or the deprecated annotation.
+// A non-empty result means deleting the class would orphan those ingresses.
+func (m *IngressManager) IngressesReferencingClass(ctx context.Context, className string) ([]types.NamespacedName, error) {
+	ingresses, err := m.ListIngresses(ctx, metav1.NamespaceAll)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	var refs []types.NamespacedName
+	for _, ing := range ingresses {
+		specMatch := ing.Spec.IngressClassName != nil && *ing.Spec.IngressClassName == className
+		if specMatch || ing.Annotations["kubernetes.io/ingress.class"] == className {
+			refs = append(refs, types.NamespacedName{Namespace: ing.Namespace, Name: ing.Name})
+		}
+	}
+	return refs, nil
+}
+
+// AnnotationInventory counts, across all namespaces, how many ingresses use
+// each distinct nginx annotation key.
+func (m *IngressManager) AnnotationInventory(ctx context.Context) (map[string]int, error) {
+	ingresses, err := m.ListIngresses(ctx, metav1.NamespaceAll)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	inventory := make(map[string]int)
+	for i := range ingresses {
+		for key := range GetNginxAnnotations(&ingresses[i]) {
+			inventory[key]++
+		}
+	}
+	return inventory, nil
+}
+
+// GetNginxAnnotations returns the ingress-nginx annotations set on an Ingress.
+func GetNginxAnnotations(ingress *networkingv1.Ingress) map[string]string {
+	out := make(map[string]string)
+	for k, v := range ingress.Annotations {
+		if strings.HasPrefix(k, "nginx.ingress.kubernetes.io/") {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// nginxBoolAnnotation parses a boolean nginx annotation with strconv.ParseBool,
+// as ingress-nginx does, so "True" and "1" count as true. ok is false when the
+// annotation is unset or not a valid boolean.
+func nginxBoolAnnotation(annotations map[string]string, key string) (value, ok bool) {
+	v, set := annotations[key]
+	if !set {
+		return false, false
+	}
+	b, err := strconv.ParseBool(v)
+	return b, err == nil
+}
+
 // SetCustomHeaders adds a configuration-snippet for custom response headers.
 func (m *IngressManager) SetCustomHeaders(ingress *networkingv1.Ingress, headers map[string]string) {
 	snippet := ""
@@ -259,32 +819,525 @@ func (m *IngressManager) SetHSTS(ingress *networkingv1.Ingress, maxAge int, incl
 	}
 }
 
-// ValidateIngress performs basic validation on an Ingress resource.
-func ValidateIngress(ingress *networkingv1.Ingress) error {
+// ValidationCode identifies the kind of problem a ValidationError reports.
+type ValidationCode string
+
+const (
+	MissingName        ValidationCode = "MissingName"
+	MissingClass       ValidationCode = "MissingClass"
+	MissingBackend     ValidationCode = "MissingBackend"
+	PortConflict       ValidationCode = "PortConflict"
+	TLSHostMismatch    ValidationCode = "TLSHostMismatch"
+	RegexPathType      ValidationCode = "RegexPathType"
+	RegexNotEnabled    ValidationCode = "RegexNotEnabled"
+	AnnotationConflict ValidationCode = "AnnotationConflict"
+	InvalidServiceName ValidationCode = "InvalidServiceName"
+	HostTooLong        ValidationCode = "HostTooLong"
+	PathTooLong        ValidationCode = "PathTooLong"
+	BodySizeTooLarge   ValidationCode = "BodySizeTooLarge"
+	InvalidAnnotation  ValidationCode = "InvalidAnnotation"
+	DuplicateTLSHost   ValidationCode = "DuplicateTLSHost"
+	SnippetForbidden   ValidationCode = "SnippetForbidden"
+)
+
+// Sentinel validation errors for use with errors.Is. A ValidationError matches
+// the sentinel with the same Code regardless of Field or Message.
+var (
+	ErrMissingName        = &ValidationError{Code: MissingName}
+	ErrMissingClass       = &ValidationError{Code: MissingClass}
+	ErrMissingBackend     = &ValidationError{Code: MissingBackend}
+	ErrPortConflict       = &ValidationError{Code: PortConflict}
+	ErrTLSHostMismatch    = &ValidationError{Code: TLSHostMismatch}
+	ErrRegexPathType      = &ValidationError{Code: RegexPathType}
+	ErrRegexNotEnabled    = &ValidationError{Code: RegexNotEnabled}
+	ErrAnnotationConflict = &ValidationError{Code: AnnotationConflict}
+	ErrInvalidServiceName = &ValidationError{Code: InvalidServiceName}
+	ErrHostTooLong        = &ValidationError{Code: HostTooLong}
+	ErrPathTooLong        = &ValidationError{Code: PathTooLong}
+	ErrBodySizeTooLarge   = &ValidationError{Code: BodySizeTooLarge}
+	ErrInvalidAnnotation  = &ValidationError{Code: InvalidAnnotation}
+	ErrDuplicateTLSHost   = &ValidationError{Code: DuplicateTLSHost}
+	ErrSnippetForbidden   = &ValidationError{Code: SnippetForbidden}
+)
+
+// DefaultMaxPathLength is the longest ingress path ValidateIngressAll accepts
+// unless WithMaxPathLength says otherwise.
+const DefaultMaxPathLength = 1024
+
+// DefaultMaxBodySize is the largest proxy-body-size, in bytes, that
+// ValidateIngressAll accepts unless WithMaxBodySize says otherwise. It matches
+// the cluster's 100m cap.
+const DefaultMaxBodySize int64 = 100 << 20
+
+// validationConfig holds the limits ValidateIngressAll checks against.
+type validationConfig struct {
+	maxPathLength  int
+	maxBodySize    int64
+	forbidSnippets bool
+}
+
+// ValidationOption adjusts the limits ValidateIngress and ValidateIngressAll
+// check against.
+type ValidationOption func(*validationConfig)
+
+// WithMaxPathLength sets the longest ingress path accepted, in characters.
+func WithMaxPathLength(n int) ValidationOption {
+	return func(c *validationConfig) { c.maxPathLength = n }
+}
+
+// WithMaxBodySize sets the largest proxy-body-size accepted, in bytes. Zero
+// or less disables the check.
+func WithMaxBodySize(n int64) ValidationOption {
+	return func(c *validationConfig) { c.maxBodySize = n }
+}
+
+// ForbidSnippetAnnotations reports every nginx *-snippet annotation as a
+// SnippetForbidden error, for clusters whose controller runs with
+// allow-snippet-annotations off. Snippets are allowed by default, since the
+// storefront relies on configuration-snippet for its security headers.
+func ForbidSnippetAnnotations() ValidationOption {
+	return func(c *validationConfig) { c.forbidSnippets = true }
+}
+
+func newValidationConfig(opts []ValidationOption) validationConfig {
+	cfg := validationConfig{
+		maxPathLength: DefaultMaxPathLength,
+		maxBodySize:   DefaultMaxBodySize,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// ValidationError describes a single validation failure on an Ingress field.
+type ValidationError struct {
+	Field   string
+	Code    ValidationCode
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Message == "" {
+		return string(e.Code)
+	}
+	return e.Message
+}
+
+// Is reports whether target is a ValidationError with the same Code.
+func (e *ValidationError) Is(target error) bool {
+	t, ok := target.(*ValidationError)
+	return ok && t.Code == e.Code
+}
+
+// ValidateIngress performs basic validation on an Ingress resource. Failures
+// are returned as *ValidationError; only the first problem found is reported.
+func ValidateIngress(ingress *networkingv1.Ingress, opts ...ValidationOption) error {
+	if errs := ValidateIngressAll(ingress, opts...); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ValidateIngressAll runs every validation check on an Ingress resource and
+// returns all failures as *ValidationError, in field order. Limits not set
+// through opts take their defaults.
+func ValidateIngressAll(ingress *networkingv1.Ingress, opts ...ValidationOption) []error {
+	cfg := newValidationConfig(opts)
+	var errs []error
+
 	if ingress.Name == "" {
-		return fmt.Errorf("ingress name cannot be empty")
+		errs = append(errs, &ValidationError{
+			Field:   "metadata.name",
+			Code:    MissingName,
+			Message: "ingress name cannot be empty",
+		})
 	}
 
 	if ingress.Spec.IngressClassName == nil {
 		if _, ok := ingress.Annotations["kubernetes.io/ingress.class"]; !ok {
-			return fmt.Errorf("ingress must specify an IngressClass via spec.ingressClassName or annotation")
+			errs = append(errs, &ValidationError{
+				Field:   "spec.ingressClassName",
+				Code:    MissingClass,
+				Message: "ingress must specify an IngressClass via spec.ingressClassName or annotation",
+			})
 		}
 	}
 
-	for _, rule := range ingress.Spec.Rules {
+	if cfg.forbidSnippets {
+		for _, key := range snippetAnnotations(ingress) {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("metadata.annotations[%s]", key),
+				Code:    SnippetForbidden,
+				Message: fmt.Sprintf("annotation %s is not allowed: snippet annotations are disabled", key),
+			})
+		}
+	}
+	errs = append(errs, validateAnnotationConflicts(ingress)...)
+	if err := validateProxyBodySize(ingress, cfg.maxBodySize); err != nil {
+		errs = append(errs, err)
+	}
+
+	hosts := make(map[string]bool)
+	for i, rule := range ingress.Spec.Rules {
+		hosts[rule.Host] = true
+		if err := validateHostLength(rule.Host, fmt.Sprintf("spec.rules[%d].host", i)); err != nil {
+			errs = append(errs, err)
+		}
 		if rule.HTTP == nil {
 			continue
 		}
-		for _, path := range rule.HTTP.Paths {
+		for j, path := range rule.HTTP.Paths {
+			if len(path.Path) > cfg.maxPathLength {
+				errs = append(errs, &ValidationError{
+					Field:   fmt.Sprintf("spec.rules[%d].http.paths[%d].path", i, j),
+					Code:    PathTooLong,
+					Message: fmt.Sprintf("ingress path %.32s... is %d characters, over the %d limit", path.Path, len(path.Path), cfg.maxPathLength),
+				})
+			}
+			if err := validatePathType(ingress, path, fmt.Sprintf("spec.rules[%d].http.paths[%d]", i, j)); err != nil {
+				errs = append(errs, err)
+			}
+			field := fmt.Sprintf("spec.rules[%d].http.paths[%d].backend.service", i, j)
 			if path.Backend.Service == nil {
-				return fmt.Errorf("ingress path %s must specify a backend service", path.Path)
+				errs = append(errs, &ValidationError{
+					Field:   field,
+					Code:    MissingBackend,
+					Message: fmt.Sprintf("ingress path %s must specify a backend service", path.Path),
+				})
+				continue
+			}
+			if msgs := validation.IsDNS1035Label(path.Backend.Service.Name); len(msgs) > 0 {
+				errs = append(errs, &ValidationError{
+					Field:   field + ".name",
+					Code:    InvalidServiceName,
+					Message: fmt.Sprintf("ingress path %s backend service name %q is not a valid DNS-1035 label: %s", path.Path, path.Backend.Service.Name, strings.Join(msgs, "; ")),
+				})
+			}
+			port := path.Backend.Service.Port
+			if port.Name != "" && port.Number != 0 {
+				errs = append(errs, &ValidationError{
+					Field:   field + ".port",
+					Code:    PortConflict,
+					Message: fmt.Sprintf("ingress path %s must specify either a port name or number, not both", path.Path),
+				})
+			}
+		}
+	}
+
+	for i, tls := range ingress.Spec.TLS {
+		for j, host := range tls.Hosts {
+			if !tlsHostServed(host, hosts) {
+				errs = append(errs, &ValidationError{
+					Field:   fmt.Sprintf("spec.tls[%d].hosts[%d]", i, j),
+					Code:    TLSHostMismatch,
+					Message: fmt.Sprintf("TLS host %s is not served by any ingress rule", host),
+				})
+			}
+		}
+	}
+
+	errs = append(errs, validateTLSHostSecrets([]networkingv1.Ingress{*ingress}, false)...)
+
+	return errs
+}
+
+// tlsHostServed reports whether a TLS host covers at least one rule host. A
+// wildcard such as "*.shop.io" covers hosts with exactly one more left-most
+// label ("api.shop.io"), as in certificate matching, but not the apex
+// "shop.io". A wildcard rule host is covered by a TLS host it matches the
+// same way.
+func tlsHostServed(tlsHost string, ruleHosts map[string]bool) bool {
+	if ruleHosts[tlsHost] {
+		return true
+	}
+	for ruleHost := range ruleHosts {
+		if wildcardMatches(tlsHost, ruleHost) || wildcardMatches(ruleHost, tlsHost) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardMatches reports whether pattern is a "*." wildcard matching host in
+// a single left-most label.
+func wildcardMatches(pattern, host string) bool {
+	suffix, ok := strings.CutPrefix(pattern, "*")
+	if !ok || !strings.HasPrefix(suffix, ".") {
+		return false
+	}
+	label, ok := strings.CutSuffix(host, suffix)
+	return ok && label != "" && !strings.Contains(label, ".")
+}
+
+// ValidateTLSHostsAcross checks a set of ingresses that are to be merged or
+// served together for hosts whose TLS certificate would be ambiguous: the same
+// host listed with different secrets, whether within one ingress or across
+// two. Ingresses in different namespaces are compared too, since a merged
+// Gateway listener has a single certificate per host.
+func ValidateTLSHostsAcross(ingresses []networkingv1.Ingress) []error {
+	return validateTLSHostSecrets(ingresses, true)
+}
+
+// validateTLSHostSecrets reports each host that appears in TLS entries with
+// two different secrets. With qualify set, fields and messages name the
+// ingress each entry came from.
+func validateTLSHostSecrets(ingresses []networkingv1.Ingress, qualify bool) []error {
+	type tlsSource struct {
+		secret string
+		where  string
+	}
+
+	var errs []error
+	first := make(map[string]tlsSource)
+	for _, ing := range ingresses {
+		for i, tls := range ing.Spec.TLS {
+			for j, host := range tls.Hosts {
+				field := fmt.Sprintf("spec.tls[%d].hosts[%d]", i, j)
+				where := fmt.Sprintf("spec.tls[%d]", i)
+				if qualify {
+					field = fmt.Sprintf("%s/%s %s", ing.Namespace, ing.Name, field)
+					where = fmt.Sprintf("%s/%s %s", ing.Namespace, ing.Name, where)
+				}
+
+				prev, ok := first[host]
+				if !ok {
+					first[host] = tlsSource{secret: tls.SecretName, where: where}
+					continue
+				}
+				if prev.secret != tls.SecretName {
+					errs = append(errs, &ValidationError{
+						Field:   field,
+						Code:    DuplicateTLSHost,
+						Message: fmt.Sprintf("TLS host %s uses secret %q in %s but secret %q in %s", host, prev.secret, prev.where, tls.SecretName, where),
+					})
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// annotationConflicts lists nginx annotation combinations whose behavior is
+// undefined or contradictory when both are present with the given values. An
+// empty value matches any value; "true" and "false" match any spelling
+// strconv.ParseBool accepts.
+var annotationConflicts = []struct {
+	key, value           string
+	otherKey, otherValue string
+}{
+	{"nginx.ingress.kubernetes.io/rewrite-target", "", "nginx.ingress.kubernetes.io/app-root", ""},
+	{"nginx.ingress.kubernetes.io/ssl-redirect", "false", "nginx.ingress.kubernetes.io/force-ssl-redirect", "true"},
+	{"nginx.ingress.kubernetes.io/canary", "true", "nginx.ingress.kubernetes.io/default-backend", ""},
+}
+
+// validateAnnotationConflicts reports each mutually exclusive annotation pair
+// present on the ingress.
+func validateAnnotationConflicts(ingress *networkingv1.Ingress) []error {
+	has := func(key, value string) bool {
+		if value == "" {
+			_, ok := ingress.Annotations[key]
+			return ok
+		}
+		want, _ := strconv.ParseBool(value)
+		got, ok := nginxBoolAnnotation(ingress.Annotations, key)
+		return ok && got == want
+	}
+
+	var errs []error
+	for _, c := range annotationConflicts {
+		if !has(c.key, c.value) || !has(c.otherKey, c.otherValue) {
+			continue
+		}
+		errs = append(errs, &ValidationError{
+			Field:   "metadata.annotations",
+			Code:    AnnotationConflict,
+			Message: fmt.Sprintf("conflicting annotations %s and %s", describeAnnotation(c.key, c.value), describeAnnotation(c.otherKey, c.otherValue)),
+		})
+	}
+	return errs
+}
+
+func describeAnnotation(key, value string) string {
+	if value == "" {
+		return key
+	}
+	return key + "=" + value
+}
+
+// validateProxyBodySize checks the proxy-body-size annotation against limit
+// bytes; limit <= 0 skips the check. A value of 0 means unlimited in nginx, so
+// it always exceeds the cap.
+func validateProxyBodySize(ingress *networkingv1.Ingress, limit int64) error {
+	value, ok := ingress.Annotations["nginx.ingress.kubernetes.io/proxy-body-size"]
+	if !ok || limit <= 0 {
+		return nil
+	}
+	size, err := ParseProxyBodySize(value)
+	if err != nil {
+		return &ValidationError{
+			Field:   "metadata.annotations[nginx.ingress.kubernetes.io/proxy-body-size]",
+			Code:    InvalidAnnotation,
+			Message: err.Error(),
+		}
+	}
+	if size == 0 || size > limit {
+		return &ValidationError{
+			Field:   "metadata.annotations[nginx.ingress.kubernetes.io/proxy-body-size]",
+			Code:    BodySizeTooLarge,
+			Message: fmt.Sprintf("proxy-body-size %s exceeds the cluster maximum of %d bytes", value, limit),
+		}
+	}
+	return nil
+}
+
+// ParseProxyBodySize parses an nginx size such as "50m", "512k" or "1g" into
+// bytes. Suffixes are case-insensitive; a bare number is bytes.
+func ParseProxyBodySize(value string) (int64, error) {
+	v := strings.TrimSpace(value)
+	multiplier := int64(1)
+	if n := len(v); n > 0 {
+		switch v[n-1] {
+		case 'k', 'K':
+			multiplier = 1 << 10
+		case 'm', 'M':
+			multiplier = 1 << 20
+		case 'g', 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier != 1 {
+			v = v[:n-1]
+		}
+	}
+	size, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid proxy-body-size %q", value)
+	}
+	if size > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("proxy-body-size %q overflows a 64-bit byte count", value)
+	}
+	return size * multiplier, nil
+}
+
+// validateHostLength enforces the DNS limits of 253 characters per hostname
+// and 63 per label.
+func validateHostLength(host, field string) error {
+	if len(host) > validation.DNS1123SubdomainMaxLength {
+		return &ValidationError{
+			Field:   field,
+			Code:    HostTooLong,
+			Message: fmt.Sprintf("host %.32s... is %d characters, over the %d limit", host, len(host), validation.DNS1123SubdomainMaxLength),
+		}
+	}
+	for _, label := range strings.Split(host, ".") {
+		if len(label) > validation.DNS1123LabelMaxLength {
+			return &ValidationError{
+				Field:   field,
+				Code:    HostTooLong,
+				Message: fmt.Sprintf("host %s has label %.32s... of %d characters, over the %d limit", host, label, len(label), validation.DNS1123LabelMaxLength),
 			}
 		}
 	}
+	return nil
+}
 
+// isRegexPath reports whether a path looks like an nginx regular expression.
+func isRegexPath(path string) bool {
+	return strings.ContainsAny(path, "(*$")
+}
+
+// validatePathType checks that a regex-looking path uses
+// ImplementationSpecific, and that the ingress enables regex matching for it
+// via use-regex or rewrite-target.
+func validatePathType(ingress *networkingv1.Ingress, path networkingv1.HTTPIngressPath, field string) error {
+	if !isRegexPath(path.Path) {
+		return nil
+	}
+	if path.PathType == nil || *path.PathType != networkingv1.PathTypeImplementationSpecific {
+		pathType := "<unset>"
+		if path.PathType != nil {
+			pathType = string(*path.PathType)
+		}
+		return &ValidationError{
+			Field:   field + ".pathType",
+			Code:    RegexPathType,
+			Message: fmt.Sprintf("ingress path %s looks like a regex but has pathType %s; regex paths require ImplementationSpecific", path.Path, pathType),
+		}
+	}
+	_, rewrite := ingress.Annotations["nginx.ingress.kubernetes.io/rewrite-target"]
+	useRegex, _ := nginxBoolAnnotation(ingress.Annotations, "nginx.ingress.kubernetes.io/use-regex")
+	if !useRegex && !rewrite {
+		return &ValidationError{
+			Field:   field + ".path",
+			Code:    RegexNotEnabled,
+			Message: fmt.Sprintf("ingress path %s looks like a regex but neither nginx.ingress.kubernetes.io/use-regex is true nor nginx.ingress.kubernetes.io/rewrite-target is set", path.Path),
+		}
+	}
 	return nil
 }
 
+// IngressWarnings reports problems that don't make an Ingress invalid but are
+// likely to cause surprising behavior.
+func IngressWarnings(ingress *networkingv1.Ingress) []string {
+	var warnings []string
+
+	if class, ok := ingress.Annotations["kubernetes.io/ingress.class"]; ok && ingress.Spec.IngressClassName != nil {
+		if class != *ingress.Spec.IngressClassName {
+			warnings = append(warnings, fmt.Sprintf(
+				"spec.ingressClassName %q disagrees with deprecated kubernetes.io/ingress.class annotation %q",
+				*ingress.Spec.IngressClassName, class))
+		}
+	}
+
+	warnings = append(warnings, catchAllRuleWarnings(ingress)...)
+	warnings = append(warnings, canaryWarnings(ingress)...)
+
+	return warnings
+}
+
+// catchAllRuleWarnings flags HTTP rules with an empty host when other rules
+// name a host, since the blank one then silently catches every other hostname.
+// A lone hostless rule is treated as an intentional catch-all.
+func catchAllRuleWarnings(ingress *networkingv1.Ingress) []string {
+	hosted := false
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" {
+			hosted = true
+			break
+		}
+	}
+	if !hosted {
+		return nil
+	}
+
+	var warnings []string
+	for i, rule := range ingress.Spec.Rules {
+		if rule.Host == "" && rule.HTTP != nil {
+			warnings = append(warnings, fmt.Sprintf(
+				"spec.rules[%d] has no host and will match every hostname not claimed by the other rules", i))
+		}
+	}
+	return warnings
+}
+
+// HasDeprecatedClassAnnotation reports whether the Ingress still carries the
+// deprecated kubernetes.io/ingress.class annotation.
+func HasDeprecatedClassAnnotation(ingress *networkingv1.Ingress) bool {
+	_, ok := ingress.Annotations["kubernetes.io/ingress.class"]
+	return ok
+}
+
+// RemoveDeprecatedClassAnnotation drops the deprecated kubernetes.io/ingress.class
+// annotation once spec.ingressClassName is set. It reports whether the
+// annotation was removed.
+func RemoveDeprecatedClassAnnotation(ingress *networkingv1.Ingress) bool {
+	if ingress.Spec.IngressClassName == nil || !HasDeprecatedClassAnnotation(ingress) {
+		return false
+	}
+	delete(ingress.Annotations, "kubernetes.io/ingress.class")
+	return true
+}
+
 // IngressToString provides a human-readable summary of an Ingress resource.
 func IngressToString(ingress *networkingv1.Ingress) string {
 	summary := fmt.Sprintf("Ingress: %s/%s\n", ingress.Namespace, ingress.Name)
@@ -295,10 +1348,10 @@ func IngressToString(ingress *networkingv1.Ingress) string {
 		summary += fmt.Sprintf("  Host: %s\n", rule.Host)
 		if rule.HTTP != nil {
 			for _, path := range rule.HTTP.Paths {
-				summary += fmt.Sprintf("    Path: %s -> %s:%d\n",
+				summary += fmt.Sprintf("    Path: %s -> %s:%s\n",
 					path.Path,
 					path.Backend.Service.Name,
-					path.Backend.Service.Port.Number,
+					servicePortString(path.Backend.Service.Port),
 				)
 			}
 		}
@@ -310,3 +1363,11 @@ func IngressToString(ingress *networkingv1.Ingress) string {
 	}
 	return summary
 }
+
+// servicePortString renders a ServiceBackendPort as either its name or number.
+func servicePortString(port networkingv1.ServiceBackendPort) string {
+	if port.Name != "" {
+		return port.Name
+	}
+	return strconv.Itoa(int(port.Number))
+}
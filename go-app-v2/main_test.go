@@ -0,0 +1,1541 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestBuildBasicIngressNamedPort(t *testing.T) {
+	m := &IngressManager{}
+	ingress := m.BuildBasicIngressNamedPort("web", "storefront", "shop.orcapod.io", "/", "web-frontend", "http")
+
+	svc := ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service
+	if svc.Port.Name != "http" || svc.Port.Number != 0 {
+		t.Fatalf("port = %+v, want name http and no number", svc.Port)
+	}
+	if err := ValidateIngress(ingress); err != nil {
+		t.Fatalf("ValidateIngress() = %v, want nil", err)
+	}
+	if got := IngressToString(ingress); !strings.Contains(got, "/ -> web-frontend:http") {
+		t.Errorf("IngressToString() = %q, want the named port", got)
+	}
+}
+
+func TestServicePort(t *testing.T) {
+	tests := []struct {
+		name    string
+		port    networkingv1.ServiceBackendPort
+		want    string
+		wantErr error
+	}{
+		{name: "number", port: networkingv1.ServiceBackendPort{Number: 8080}, want: "8080"},
+		{name: "name", port: networkingv1.ServiceBackendPort{Name: "http"}, want: "http"},
+		{name: "both", port: networkingv1.ServiceBackendPort{Name: "http", Number: 8080}, want: "http", wantErr: ErrPortConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := servicePortString(tt.port); got != tt.want {
+				t.Errorf("servicePortString() = %q, want %q", got, tt.want)
+			}
+
+			m := &IngressManager{}
+			ingress := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 0)
+			ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port = tt.port
+			err := ValidateIngress(ingress)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("ValidateIngress() = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateIngress() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateIngressErrorField(t *testing.T) {
+	tests := []struct {
+		name      string
+		mutate    func(*networkingv1.Ingress)
+		opts      []ValidationOption
+		wantErr   error
+		wantField string
+	}{
+		{
+			name:      "missing name",
+			mutate:    func(ing *networkingv1.Ingress) { ing.Name = "" },
+			wantErr:   ErrMissingName,
+			wantField: "metadata.name",
+		},
+		{
+			name: "missing class",
+			mutate: func(ing *networkingv1.Ingress) {
+				ing.Spec.IngressClassName = nil
+				delete(ing.Annotations, "kubernetes.io/ingress.class")
+			},
+			wantErr:   ErrMissingClass,
+			wantField: "spec.ingressClassName",
+		},
+		{
+			name:      "missing backend",
+			mutate:    func(ing *networkingv1.Ingress) { ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service = nil },
+			wantErr:   ErrMissingBackend,
+			wantField: "spec.rules[0].http.paths[0].backend.service",
+		},
+		{
+			name: "forbidden snippet",
+			mutate: func(ing *networkingv1.Ingress) {
+				ing.Annotations["nginx.ingress.kubernetes.io/server-snippet"] = "return 403;"
+			},
+			opts:      []ValidationOption{ForbidSnippetAnnotations()},
+			wantErr:   ErrSnippetForbidden,
+			wantField: "metadata.annotations[nginx.ingress.kubernetes.io/server-snippet]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			ingress := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+			tt.mutate(ingress)
+
+			err := ValidateIngress(ingress, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateIngress() = %v, want %v", err, tt.wantErr)
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("ValidateIngress() = %T, want *ValidationError", err)
+			}
+			if verr.Field != tt.wantField {
+				t.Errorf("Field = %q, want %q", verr.Field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestValidateIngressSnippetsAllowedByDefault(t *testing.T) {
+	m := &IngressManager{}
+	ingress := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	m.SetCustomHeaders(ingress, map[string]string{"X-Frame-Options": "DENY"})
+
+	if err := ValidateIngress(ingress); err != nil {
+		t.Errorf("ValidateIngress() = %v, want nil", err)
+	}
+}
+
+func TestValidateIngressAllReportsEveryProblem(t *testing.T) {
+	m := &IngressManager{}
+	ingress := m.BuildBasicIngress("", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	ingress.Spec.IngressClassName = nil
+	delete(ingress.Annotations, "kubernetes.io/ingress.class")
+	ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service = nil
+
+	errs := ValidateIngressAll(ingress)
+	want := []error{ErrMissingName, ErrMissingClass, ErrMissingBackend}
+	if len(errs) != len(want) {
+		t.Fatalf("ValidateIngressAll() returned %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i, err := range errs {
+		if !errors.Is(err, want[i]) {
+			t.Errorf("errs[%d] = %v, want %v", i, err, want[i])
+		}
+	}
+	if err := ValidateIngress(ingress); !errors.Is(err, ErrMissingName) {
+		t.Errorf("ValidateIngress() = %v, want the first error, %v", err, ErrMissingName)
+	}
+}
+
+func TestValidateIngressAllTLSHosts(t *testing.T) {
+	tests := []struct {
+		name     string
+		ruleHost string
+		tlsHost  string
+		wantErr  bool
+	}{
+		{name: "exact", ruleHost: "shop.orcapod.io", tlsHost: "shop.orcapod.io"},
+		{name: "wildcard covers subdomain", ruleHost: "api.orcapod.io", tlsHost: "*.orcapod.io"},
+		{name: "wildcard rule host", ruleHost: "*.orcapod.io", tlsHost: "api.orcapod.io"},
+		{name: "wildcard skips apex", ruleHost: "orcapod.io", tlsHost: "*.orcapod.io", wantErr: true},
+		{name: "wildcard is one label", ruleHost: "v1.api.orcapod.io", tlsHost: "*.orcapod.io", wantErr: true},
+		{name: "unrelated host", ruleHost: "shop.orcapod.io", tlsHost: "admin.orcapod.io", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			ingress := m.BuildBasicIngress("web", "storefront", tt.ruleHost, "/", "web-frontend", 8080)
+			ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{tt.tlsHost}, SecretName: "shop-tls"}}
+
+			errs := ValidateIngressAll(ingress)
+			if tt.wantErr {
+				if len(errs) != 1 || !errors.Is(errs[0], ErrTLSHostMismatch) {
+					t.Errorf("ValidateIngressAll() = %v, want a single %v", errs, ErrTLSHostMismatch)
+				}
+			} else if len(errs) > 0 {
+				t.Errorf("ValidateIngressAll() = %v, want none", errs)
+			}
+		})
+	}
+}
+
+func TestDeprecatedClassAnnotation(t *testing.T) {
+	tests := []struct {
+		name        string
+		specClass   string
+		annotation  string
+		wantWarning bool
+		wantRemoved bool
+	}{
+		{name: "agree", specClass: "nginx", annotation: "nginx", wantRemoved: true},
+		{name: "disagree", specClass: "nginx", annotation: "nginx-internal", wantWarning: true, wantRemoved: true},
+		{name: "annotation only", annotation: "nginx"},
+		{name: "spec only", specClass: "nginx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			ingress := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+			ingress.Spec.IngressClassName = nil
+			if tt.specClass != "" {
+				class := tt.specClass
+				ingress.Spec.IngressClassName = &class
+			}
+			delete(ingress.Annotations, "kubernetes.io/ingress.class")
+			if tt.annotation != "" {
+				ingress.Annotations["kubernetes.io/ingress.class"] = tt.annotation
+			}
+
+			if got := HasDeprecatedClassAnnotation(ingress); got != (tt.annotation != "") {
+				t.Errorf("HasDeprecatedClassAnnotation() = %v", got)
+			}
+			warned := false
+			for _, w := range IngressWarnings(ingress) {
+				if strings.Contains(w, "kubernetes.io/ingress.class") {
+					warned = true
+				}
+			}
+			if warned != tt.wantWarning {
+				t.Errorf("IngressWarnings() class warning = %v, want %v", warned, tt.wantWarning)
+			}
+
+			if got := RemoveDeprecatedClassAnnotation(ingress); got != tt.wantRemoved {
+				t.Errorf("RemoveDeprecatedClassAnnotation() = %v, want %v", got, tt.wantRemoved)
+			}
+			if got := HasDeprecatedClassAnnotation(ingress); got != (tt.annotation != "" && !tt.wantRemoved) {
+				t.Errorf("annotation still present = %v after removal", got)
+			}
+		})
+	}
+}
+
+func TestNormalizeIngressClass(t *testing.T) {
+	tests := []struct {
+		name           string
+		specClass      string
+		annotation     string
+		wantUpdated    bool
+		wantAnnotation bool
+	}{
+		{name: "unclassed", wantUpdated: true},
+		{name: "annotation only", annotation: "nginx", wantUpdated: true},
+		{name: "spec and annotation", specClass: "nginx", annotation: "nginx", wantUpdated: true},
+		{name: "already normalized", specClass: "nginx"},
+		{name: "other class annotation", annotation: "traefik", wantAnnotation: true},
+		{name: "other class spec", specClass: "traefik"},
+	}
+
+	m := &IngressManager{}
+	clientset := fake.NewSimpleClientset()
+	for _, tt := range tests {
+		ingress := m.BuildBasicIngress(strings.ReplaceAll(tt.name, " ", "-"), "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+		ingress.Spec.IngressClassName = nil
+		if tt.specClass != "" {
+			class := tt.specClass
+			ingress.Spec.IngressClassName = &class
+		}
+		delete(ingress.Annotations, "kubernetes.io/ingress.class")
+		if tt.annotation != "" {
+			ingress.Annotations["kubernetes.io/ingress.class"] = tt.annotation
+		}
+		if err := clientset.Tracker().Add(ingress); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	changed, err := NewIngressManager(clientset).NormalizeIngressClass(ctx, "storefront", "nginx")
+	if err != nil {
+		t.Fatalf("NormalizeIngressClass() error = %v", err)
+	}
+	wantChanged := 0
+	for _, tt := range tests {
+		if tt.wantUpdated {
+			wantChanged++
+		}
+	}
+	if changed != wantChanged {
+		t.Errorf("NormalizeIngressClass() = %d, want %d", changed, wantChanged)
+	}
+
+	updates := 0
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "update" {
+			updates++
+		}
+	}
+	if updates != wantChanged {
+		t.Errorf("%d updates issued, want %d (normalized ingresses must not be written)", updates, wantChanged)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ing, err := clientset.NetworkingV1().Ingresses("storefront").Get(ctx, strings.ReplaceAll(tt.name, " ", "-"), metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantUpdated && getIngressClassName(ing) != "nginx" {
+				t.Errorf("class = %q, want nginx", getIngressClassName(ing))
+			}
+			if got := HasDeprecatedClassAnnotation(ing); got != tt.wantAnnotation {
+				t.Errorf("deprecated annotation present = %v, want %v", got, tt.wantAnnotation)
+			}
+		})
+	}
+}
+
+// ingressWithAddress returns a copy of ingress whose status reports a single
+// load balancer entry with the given IP and hostname.
+func ingressWithAddress(ingress *networkingv1.Ingress, ip, hostname string) *networkingv1.Ingress {
+	ing := ingress.DeepCopy()
+	ing.Status.LoadBalancer.Ingress = []networkingv1.IngressLoadBalancerIngress{{IP: ip, Hostname: hostname}}
+	return ing
+}
+
+func TestWaitForIngressAddress(t *testing.T) {
+	base := (&IngressManager{}).BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	hostnameOnly := ingressWithAddress(base, "", "lb.elb.amazonaws.com")
+	ipOnly := ingressWithAddress(base, "203.0.113.10", "")
+
+	tests := []struct {
+		name    string
+		wait    func(*IngressManager, context.Context) (string, error)
+		replies []runtime.Object
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "IP waiter skips hostname",
+			wait: func(m *IngressManager, ctx context.Context) (string, error) {
+				return m.WaitForIngressIP(ctx, "storefront", "web")
+			},
+			replies: []runtime.Object{hostnameOnly, ipOnly},
+			want:    "203.0.113.10",
+		},
+		{
+			name: "hostname waiter",
+			wait: func(m *IngressManager, ctx context.Context) (string, error) {
+				return m.WaitForIngressHostname(ctx, "storefront", "web")
+			},
+			replies: []runtime.Object{hostnameOnly},
+			want:    "lb.elb.amazonaws.com",
+		},
+		{
+			name: "either kind",
+			wait: func(m *IngressManager, ctx context.Context) (string, error) {
+				return m.WaitForIngressAddress(ctx, "storefront", "web")
+			},
+			replies: []runtime.Object{hostnameOnly},
+			want:    "lb.elb.amazonaws.com",
+		},
+		{
+			name: "forbidden stops the wait",
+			wait: func(m *IngressManager, ctx context.Context) (string, error) {
+				return m.WaitForIngressAddress(ctx, "storefront", "web")
+			},
+			replies: []runtime.Object{nil},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			calls := 0
+			clientset.PrependReactor("get", "ingresses", func(k8stesting.Action) (bool, runtime.Object, error) {
+				reply := tt.replies[min(calls, len(tt.replies)-1)]
+				calls++
+				if reply == nil {
+					return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}, "web", errors.New("denied"))
+				}
+				return true, reply, nil
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			got, err := tt.wait(NewIngressManager(clientset), ctx)
+			if tt.wantErr {
+				if err == nil || calls != 1 {
+					t.Errorf("wait = %q, %v after %d calls, want an error after 1 call", got, err, calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("wait error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("wait = %q, want %q", got, tt.want)
+			}
+			if calls != len(tt.replies) {
+				t.Errorf("polled %d times, want %d", calls, len(tt.replies))
+			}
+		})
+	}
+}
+
+func TestStopPolling(t *testing.T) {
+	gr := schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}
+	tests := []struct {
+		name     string
+		err      error
+		wantStop bool
+	}{
+		{name: "not found", err: apierrors.NewNotFound(gr, "web")},
+		{name: "server timeout", err: apierrors.NewServerTimeout(gr, "get", 1)},
+		{name: "too many requests", err: apierrors.NewTooManyRequests("slow down", 1)},
+		{name: "service unavailable", err: apierrors.NewServiceUnavailable("restarting")},
+		{name: "forbidden", err: apierrors.NewForbidden(gr, "web", errors.New("denied")), wantStop: true},
+		{name: "unauthorized", err: apierrors.NewUnauthorized("expired token"), wantStop: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stopPolling(tt.err); (got != nil) != tt.wantStop {
+				t.Errorf("stopPolling() = %v, want stop %v", got, tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestCopyIngress(t *testing.T) {
+	source := (&IngressManager{}).BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	source.Labels = map[string]string{"app": "storefront"}
+	source.UID = "1234"
+	source.ResourceVersion = "42"
+	source.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"shop.orcapod.io"}, SecretName: "shop-tls"}}
+	source.Status.LoadBalancer.Ingress = []networkingv1.IngressLoadBalancerIngress{{IP: "203.0.113.10"}}
+	secret := func(namespace string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shop-tls", Namespace: namespace}}
+	}
+
+	tests := []struct {
+		name         string
+		secrets      []runtime.Object
+		rename       func(string) string
+		wantName     string
+		wantWarnings int
+	}{
+		{
+			name:     "secret present",
+			secrets:  []runtime.Object{secret("storefront"), secret("storefront-blue")},
+			wantName: "web",
+		},
+		{
+			name:         "secret missing in destination",
+			secrets:      []runtime.Object{secret("storefront")},
+			rename:       func(name string) string { return name + "-blue" },
+			wantName:     "web-blue",
+			wantWarnings: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			clientset := fake.NewSimpleClientset(append(tt.secrets, source.DeepCopy())...)
+			m := NewIngressManager(clientset)
+
+			copied, warnings, err := m.CopyIngress(ctx, "storefront", "web", "storefront-blue", tt.rename)
+			if err != nil {
+				t.Fatalf("CopyIngress() error = %v", err)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %v, want %d", warnings, tt.wantWarnings)
+			}
+			for _, w := range warnings {
+				if !strings.Contains(w, "shop-tls") {
+					t.Errorf("warning %q does not name the missing secret", w)
+				}
+			}
+
+			got, err := clientset.NetworkingV1().Ingresses("storefront-blue").Get(ctx, tt.wantName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("copy not created: %v", err)
+			}
+			if got.Name != copied.Name {
+				t.Errorf("returned copy %q, created %q", copied.Name, got.Name)
+			}
+			if got.UID != "" || len(got.Status.LoadBalancer.Ingress) != 0 {
+				t.Errorf("copy kept server-populated fields: uid %q, status %+v", got.UID, got.Status)
+			}
+			if got.Labels["app"] != "storefront" || got.Spec.TLS[0].SecretName != "shop-tls" {
+				t.Errorf("copy lost labels or spec: %+v", got)
+			}
+			if _, ok := got.Annotations[OriginalUIDAnnotation]; ok {
+				t.Error("copy has provenance annotations without SetRecordCopyProvenance")
+			}
+		})
+	}
+}
+
+func TestValidatePathType(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		pathType    networkingv1.PathType
+		annotations map[string]string
+		wantErr     error
+	}{
+		{name: "clean prefix", path: "/api", pathType: networkingv1.PathTypePrefix},
+		{name: "regex with prefix type", path: "/api/(.*)", pathType: networkingv1.PathTypePrefix, wantErr: ErrRegexPathType},
+		{name: "regex with exact type", path: "/api/v[0-9]+$", pathType: networkingv1.PathTypeExact, wantErr: ErrRegexPathType},
+		{name: "regex without use-regex", path: "/api/(.*)", pathType: networkingv1.PathTypeImplementationSpecific, wantErr: ErrRegexNotEnabled},
+		{
+			name:        "regex with use-regex",
+			path:        "/api/(.*)",
+			pathType:    networkingv1.PathTypeImplementationSpecific,
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/use-regex": "true"},
+		},
+		{
+			name:        "regex with use-regex True",
+			path:        "/api/(.*)",
+			pathType:    networkingv1.PathTypeImplementationSpecific,
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/use-regex": "True"},
+		},
+		{
+			name:        "regex with use-regex 1",
+			path:        "/api/(.*)",
+			pathType:    networkingv1.PathTypeImplementationSpecific,
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/use-regex": "1"},
+		},
+		{
+			name:        "regex with use-regex false",
+			path:        "/api/(.*)",
+			pathType:    networkingv1.PathTypeImplementationSpecific,
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/use-regex": "false"},
+			wantErr:     ErrRegexNotEnabled,
+		},
+		{
+			name:        "regex with rewrite-target",
+			path:        "/api/(.*)",
+			pathType:    networkingv1.PathTypeImplementationSpecific,
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/$1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			ingress := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", tt.path, "web-frontend", 8080)
+			ingress.Spec.Rules[0].HTTP.Paths[0].PathType = &tt.pathType
+			for k, v := range tt.annotations {
+				ingress.Annotations[k] = v
+			}
+
+			err := ValidateIngress(ingress)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("ValidateIngress() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateIngress() = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.path) {
+				t.Errorf("error %q does not name path %s", err, tt.path)
+			}
+		})
+	}
+}
+
+func TestValidateAnnotationConflicts(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantPair    [2]string
+	}{
+		{
+			name: "rewrite-target and app-root",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/rewrite-target": "/$1",
+				"nginx.ingress.kubernetes.io/app-root":       "/home",
+			},
+			wantPair: [2]string{"rewrite-target", "app-root"},
+		},
+		{
+			name: "ssl-redirect off and force-ssl-redirect on",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect":       "false",
+				"nginx.ingress.kubernetes.io/force-ssl-redirect": "true",
+			},
+			wantPair: [2]string{"ssl-redirect=false", "force-ssl-redirect=true"},
+		},
+		{
+			name: "redirects spelled 0 and 1",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect":       "0",
+				"nginx.ingress.kubernetes.io/force-ssl-redirect": "1",
+			},
+			wantPair: [2]string{"ssl-redirect=false", "force-ssl-redirect=true"},
+		},
+		{
+			name: "canary True and default-backend",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":          "True",
+				"nginx.ingress.kubernetes.io/default-backend": "fallback",
+			},
+			wantPair: [2]string{"canary=true", "default-backend"},
+		},
+		{
+			name: "canary and default-backend",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":          "true",
+				"nginx.ingress.kubernetes.io/default-backend": "fallback",
+			},
+			wantPair: [2]string{"canary=true", "default-backend"},
+		},
+		{
+			name: "both redirects on",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect":       "true",
+				"nginx.ingress.kubernetes.io/force-ssl-redirect": "true",
+			},
+		},
+		{
+			name: "canary off with default-backend",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/canary":          "false",
+				"nginx.ingress.kubernetes.io/default-backend": "fallback",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			ingress := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+			for k, v := range tt.annotations {
+				ingress.Annotations[k] = v
+			}
+
+			err := ValidateIngress(ingress)
+			if tt.wantPair[0] == "" {
+				if err != nil {
+					t.Errorf("ValidateIngress() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrAnnotationConflict) {
+				t.Fatalf("ValidateIngress() = %v, want %v", err, ErrAnnotationConflict)
+			}
+			for _, key := range tt.wantPair {
+				if !strings.Contains(err.Error(), "nginx.ingress.kubernetes.io/"+key) {
+					t.Errorf("error %q does not name %s", err, key)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateServiceName(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		wantErr bool
+	}{
+		{name: "valid", service: "web-frontend"},
+		{name: "underscore", service: "web_frontend", wantErr: true},
+		{name: "uppercase", service: "WebFrontend", wantErr: true},
+		{name: "leading digit", service: "1web", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			ingress := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/api", tt.service, 8080)
+
+			err := ValidateIngress(ingress)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("ValidateIngress() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidServiceName) {
+				t.Fatalf("ValidateIngress() = %v, want %v", err, ErrInvalidServiceName)
+			}
+			if msg := err.Error(); !strings.Contains(msg, tt.service) || !strings.Contains(msg, "/api") {
+				t.Errorf("error %q does not name service %s and path /api", msg, tt.service)
+			}
+		})
+	}
+}
+
+func TestEnsureIngressClass(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   []runtime.Object
+		createErr  error
+		wantErr    bool
+		wantCreate bool
+	}{
+		{name: "creates missing class", wantCreate: true},
+		{
+			name:     "class already present",
+			existing: []runtime.Object{&networkingv1.IngressClass{ObjectMeta: metav1.ObjectMeta{Name: "nginx"}}},
+		},
+		{
+			name:       "lost create race",
+			createErr:  apierrors.NewAlreadyExists(schema.GroupResource{Group: "networking.k8s.io", Resource: "ingressclasses"}, "nginx"),
+			wantCreate: true,
+		},
+		{
+			name:       "create forbidden",
+			createErr:  apierrors.NewForbidden(schema.GroupResource{Group: "networking.k8s.io", Resource: "ingressclasses"}, "nginx", errors.New("denied")),
+			wantErr:    true,
+			wantCreate: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(tt.existing...)
+			if tt.createErr != nil {
+				clientset.PrependReactor("create", "ingressclasses", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tt.createErr
+				})
+			}
+			m := NewIngressManager(clientset)
+
+			err := m.EnsureIngressClass(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EnsureIngressClass() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			created := false
+			for _, action := range clientset.Actions() {
+				if action.Matches("create", "ingressclasses") {
+					created = true
+				}
+			}
+			if created != tt.wantCreate {
+				t.Errorf("create called = %v, want %v", created, tt.wantCreate)
+			}
+		})
+	}
+}
+
+func TestCreateIngressClass(t *testing.T) {
+	tests := []struct {
+		name      string
+		isDefault bool
+	}{
+		{name: "default", isDefault: true},
+		{name: "non-default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			clientset := fake.NewSimpleClientset()
+			m := NewIngressManager(clientset)
+			if _, err := m.CreateIngressClass(ctx, "nginx", tt.isDefault); err != nil {
+				t.Fatalf("CreateIngressClass() error = %v", err)
+			}
+
+			class, err := clientset.NetworkingV1().IngressClasses().Get(ctx, "nginx", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if class.Spec.Controller != "k8s.io/ingress-nginx" {
+				t.Errorf("controller = %q, want k8s.io/ingress-nginx", class.Spec.Controller)
+			}
+			if got := class.Annotations["ingressclass.kubernetes.io/is-default-class"] == "true"; got != tt.isDefault {
+				t.Errorf("is-default-class = %v, want %v", got, tt.isDefault)
+			}
+		})
+	}
+
+	t.Run("EnsureIngressClass is not default", func(t *testing.T) {
+		ctx := context.Background()
+		clientset := fake.NewSimpleClientset()
+		if err := NewIngressManager(clientset).EnsureIngressClass(ctx); err != nil {
+			t.Fatalf("EnsureIngressClass() error = %v", err)
+		}
+		class, err := clientset.NetworkingV1().IngressClasses().Get(ctx, "nginx", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := class.Annotations["ingressclass.kubernetes.io/is-default-class"]; ok {
+			t.Errorf("EnsureIngressClass created a default class: %v", class.Annotations)
+		}
+	})
+}
+
+func TestIngressesReferencingClass(t *testing.T) {
+	builder := &IngressManager{}
+	bySpec := builder.BuildBasicIngress("storefront", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	delete(bySpec.Annotations, "kubernetes.io/ingress.class")
+	byAnnotation := builder.BuildBasicIngress("dashboard", "admin", "admin.orcapod.io", "/", "dashboard", 8080)
+	byAnnotation.Spec.IngressClassName = nil
+	other := builder.BuildBasicIngress("docs", "docs", "docs.orcapod.io", "/", "docs", 8080)
+	traefik := "traefik"
+	other.Spec.IngressClassName = &traefik
+	delete(other.Annotations, "kubernetes.io/ingress.class")
+	classless := builder.BuildBasicIngress("legacy", "legacy", "legacy.orcapod.io", "/", "legacy", 8080)
+	classless.Spec.IngressClassName = nil
+	delete(classless.Annotations, "kubernetes.io/ingress.class")
+	m := NewIngressManager(fake.NewSimpleClientset(bySpec, byAnnotation, other, classless))
+
+	tests := []struct {
+		className string
+		want      []types.NamespacedName
+	}{
+		{
+			className: "nginx",
+			want: []types.NamespacedName{
+				{Namespace: "admin", Name: "dashboard"},
+				{Namespace: "storefront", Name: "storefront"},
+			},
+		},
+		{className: "traefik", want: []types.NamespacedName{{Namespace: "docs", Name: "docs"}}},
+		{className: "haproxy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.className, func(t *testing.T) {
+			got, err := m.IngressesReferencingClass(context.Background(), tt.className)
+			if err != nil {
+				t.Fatalf("IngressesReferencingClass() error = %v", err)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i].String() < got[j].String() })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IngressesReferencingClass(%s) = %v, want %v", tt.className, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvisionStorefrontDryRun(t *testing.T) {
+	tests := []struct {
+		name          string
+		dryRun        bool
+		wantDryRun    int
+		wantPersisted int
+	}{
+		{name: "dry run", dryRun: true, wantDryRun: 2},
+		{name: "real run", wantPersisted: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			m := NewIngressManager(clientset)
+			if err := provisionStorefront(context.Background(), m, tt.dryRun); err != nil {
+				t.Fatalf("provisionStorefront() error = %v", err)
+			}
+
+			var dryRun, persisted int
+			for _, action := range clientset.Actions() {
+				create, ok := action.(k8stesting.CreateActionImpl)
+				if !ok {
+					continue
+				}
+				if opts := create.GetCreateOptions(); len(opts.DryRun) == 1 && opts.DryRun[0] == metav1.DryRunAll {
+					dryRun++
+				} else {
+					persisted++
+				}
+			}
+			if dryRun != tt.wantDryRun || persisted != tt.wantPersisted {
+				t.Errorf("got %d dry-run and %d persisted creates, want %d and %d", dryRun, persisted, tt.wantDryRun, tt.wantPersisted)
+			}
+		})
+	}
+}
+
+func TestValidateHostAndPathLength(t *testing.T) {
+	label63 := strings.Repeat("a", 63)
+	tests := []struct {
+		name    string
+		host    string
+		path    string
+		opts    []ValidationOption
+		wantErr error
+	}{
+		{name: "valid", host: label63 + ".orcapod.io", path: "/" + strings.Repeat("p", DefaultMaxPathLength-1)},
+		{name: "host over 253", host: strings.Repeat(label63+".", 4) + "io", path: "/", wantErr: ErrHostTooLong},
+		{name: "label over 63", host: label63 + "a.orcapod.io", path: "/", wantErr: ErrHostTooLong},
+		{name: "path over default", host: "shop.orcapod.io", path: "/" + strings.Repeat("p", DefaultMaxPathLength), wantErr: ErrPathTooLong},
+		{name: "path over custom", host: "shop.orcapod.io", path: "/api/v1/orders", opts: []ValidationOption{WithMaxPathLength(8)}, wantErr: ErrPathTooLong},
+		{name: "path within custom", host: "shop.orcapod.io", path: "/api", opts: []ValidationOption{WithMaxPathLength(8)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			ingress := m.BuildBasicIngress("web", "storefront", tt.host, tt.path, "web-frontend", 8080)
+
+			err := ValidateIngress(ingress, tt.opts...)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("ValidateIngress() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateIngress() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFilterIngresses(t *testing.T) {
+	named := func(name string, annotations map[string]string) networkingv1.Ingress {
+		ing := annotatedIngress(annotations)
+		ing.Name = name
+		return *ing
+	}
+	ingresses := []networkingv1.Ingress{
+		named("canary", map[string]string{"nginx.ingress.kubernetes.io/canary": "true", "nginx.ingress.kubernetes.io/canary-weight": "10"}),
+		named("stable", map[string]string{"nginx.ingress.kubernetes.io/canary": "false"}),
+		named("limited", map[string]string{"nginx.ingress.kubernetes.io/limit-rps": "50"}),
+		named("plain", nil),
+	}
+
+	tests := []struct {
+		name string
+		pred func(map[string]string) bool
+		want []string
+	}{
+		{name: "canary=true", pred: WithAnnotation("nginx.ingress.kubernetes.io/canary", "true"), want: []string{"canary"}},
+		{name: "any canary value", pred: WithAnnotation("nginx.ingress.kubernetes.io/canary", ""), want: []string{"canary", "stable"}},
+		{name: "rate limited", pred: WithAnnotation("nginx.ingress.kubernetes.io/limit-rps", "50"), want: []string{"limited"}},
+		{name: "no match", pred: WithAnnotation("nginx.ingress.kubernetes.io/limit-rps", "10")},
+		{name: "custom predicate", pred: func(a map[string]string) bool { return len(a) == 0 }, want: []string{"plain"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, ing := range FilterIngresses(ingresses, tt.pred) {
+				got = append(got, ing.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterIngresses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissingTLSSecrets(t *testing.T) {
+	present := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shop-orcapod-tls", Namespace: "storefront"}}
+	elsewhere := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "api-orcapod-tls", Namespace: "other"}}
+
+	tests := []struct {
+		name    string
+		tls     []networkingv1.IngressTLS
+		getErr  error
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "one present one missing",
+			tls: []networkingv1.IngressTLS{
+				{Hosts: []string{"shop.orcapod.io"}, SecretName: "shop-orcapod-tls"},
+				{Hosts: []string{"api.orcapod.io"}, SecretName: "api-orcapod-tls"},
+				{Hosts: []string{"www.orcapod.io"}, SecretName: "api-orcapod-tls"},
+			},
+			want: []string{"api-orcapod-tls"},
+		},
+		{name: "all present", tls: []networkingv1.IngressTLS{{SecretName: "shop-orcapod-tls"}}},
+		{name: "no secret name", tls: []networkingv1.IngressTLS{{Hosts: []string{"shop.orcapod.io"}}}},
+		{
+			name:    "lookup forbidden",
+			tls:     []networkingv1.IngressTLS{{SecretName: "shop-orcapod-tls"}},
+			getErr:  apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "shop-orcapod-tls", errors.New("denied")),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(present, elsewhere)
+			if tt.getErr != nil {
+				clientset.PrependReactor("get", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tt.getErr
+				})
+			}
+			m := NewIngressManager(clientset)
+			ingress := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+			ingress.Spec.TLS = tt.tls
+
+			got, err := m.MissingTLSSecrets(context.Background(), ingress)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MissingTLSSecrets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MissingTLSSecrets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchAnnotation(t *testing.T) {
+	const key = "nginx.ingress.kubernetes.io/limit-rps"
+	tests := []struct {
+		name    string
+		initial map[string]string
+		value   string
+		want    map[string]string
+	}{
+		{
+			name:  "set",
+			value: "50",
+			want:  map[string]string{"kubernetes.io/ingress.class": "nginx", key: "50"},
+		},
+		{
+			name:    "overwrite",
+			initial: map[string]string{key: "100"},
+			value:   "50",
+			want:    map[string]string{"kubernetes.io/ingress.class": "nginx", key: "50"},
+		},
+		{
+			name:    "remove",
+			initial: map[string]string{key: "100"},
+			want:    map[string]string{"kubernetes.io/ingress.class": "nginx"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			builder := &IngressManager{}
+			ingress := builder.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+			for k, v := range tt.initial {
+				ingress.Annotations[k] = v
+			}
+			m := NewIngressManager(fake.NewSimpleClientset(ingress))
+
+			if err := m.PatchAnnotation(ctx, "storefront", "web", key, tt.value); err != nil {
+				t.Fatalf("PatchAnnotation() error = %v", err)
+			}
+			got, err := m.GetIngress(ctx, "storefront", "web")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Annotations, tt.want) {
+				t.Errorf("annotations = %v, want %v", got.Annotations, tt.want)
+			}
+			if got.Spec.Rules[0].Host != "shop.orcapod.io" {
+				t.Errorf("patch changed the spec: %+v", got.Spec)
+			}
+		})
+	}
+
+	t.Run("missing ingress", func(t *testing.T) {
+		m := NewIngressManager(fake.NewSimpleClientset())
+		if err := m.PatchAnnotation(context.Background(), "storefront", "web", key, "50"); !apierrors.IsNotFound(err) {
+			t.Errorf("PatchAnnotation() error = %v, want NotFound", err)
+		}
+	})
+}
+
+func TestValidateProxyBodySize(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		opts    []ValidationOption
+		wantErr error
+	}{
+		{name: "within default cap", value: "50m"},
+		{name: "at default cap", value: "100m"},
+		{name: "over default cap", value: "200m", wantErr: ErrBodySizeTooLarge},
+		{name: "unlimited", value: "0", wantErr: ErrBodySizeTooLarge},
+		{name: "over custom cap", value: "50m", opts: []ValidationOption{WithMaxBodySize(10 << 20)}, wantErr: ErrBodySizeTooLarge},
+		{name: "check disabled", value: "1g", opts: []ValidationOption{WithMaxBodySize(0)}},
+		{name: "unparseable", value: "lots", wantErr: ErrInvalidAnnotation},
+		{name: "overflow", value: "9223372036854775807g", wantErr: ErrInvalidAnnotation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			ingress := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+			ingress.Annotations["nginx.ingress.kubernetes.io/proxy-body-size"] = tt.value
+
+			err := ValidateIngress(ingress, tt.opts...)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("ValidateIngress() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateIngress() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseProxyBodySize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "512", want: 512},
+		{value: "512k", want: 512 << 10},
+		{value: "50M", want: 50 << 20},
+		{value: " 1g ", want: 1 << 30},
+		{value: "-1m", wantErr: true},
+		{value: "m", wantErr: true},
+		{value: "", wantErr: true},
+		{value: "9007199254740992g", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseProxyBodySize(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseProxyBodySize(%q) = %d, %v, want %d, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAnnotationInventory(t *testing.T) {
+	builder := &IngressManager{}
+	web := builder.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	web.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "true"
+	web.Annotations["nginx.ingress.kubernetes.io/limit-rps"] = "50"
+	api := builder.BuildBasicIngress("api", "storefront", "api.orcapod.io", "/", "api-backend", 8080)
+	api.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "false"
+	dashboard := builder.BuildBasicIngress("dashboard", "admin", "admin.orcapod.io", "/", "dashboard", 8080)
+	dashboard.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "true"
+	dashboard.Annotations["cert-manager.io/cluster-issuer"] = "letsencrypt"
+
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		want    map[string]int
+	}{
+		{
+			name:    "across namespaces",
+			objects: []runtime.Object{web, api, dashboard},
+			want: map[string]int{
+				"nginx.ingress.kubernetes.io/ssl-redirect": 3,
+				"nginx.ingress.kubernetes.io/limit-rps":    1,
+			},
+		},
+		{name: "no ingresses", want: map[string]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewIngressManager(fake.NewSimpleClientset(tt.objects...))
+			got, err := m.AnnotationInventory(context.Background())
+			if err != nil {
+				t.Fatalf("AnnotationInventory() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AnnotationInventory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCatchAllRuleWarnings(t *testing.T) {
+	tests := []struct {
+		name         string
+		hosts        []string
+		wantWarnings []string
+	}{
+		{name: "lone catch-all", hosts: []string{""}},
+		{name: "hosts only", hosts: []string{"shop.orcapod.io", "api.orcapod.io"}},
+		{
+			name:         "blank host among hosts",
+			hosts:        []string{"shop.orcapod.io", ""},
+			wantWarnings: []string{"spec.rules[1] has no host and will match every hostname not claimed by the other rules"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			ingress := m.BuildBasicIngress("web", "storefront", tt.hosts[0], "/", "web-frontend", 8080)
+			for _, host := range tt.hosts[1:] {
+				rule := *ingress.Spec.Rules[0].DeepCopy()
+				rule.Host = host
+				ingress.Spec.Rules = append(ingress.Spec.Rules, rule)
+			}
+
+			if got := IngressWarnings(ingress); !reflect.DeepEqual(got, tt.wantWarnings) {
+				t.Errorf("IngressWarnings() = %q, want %q", got, tt.wantWarnings)
+			}
+			if err := ValidateIngress(ingress); err != nil {
+				t.Errorf("ValidateIngress() = %v, want catch-all rules to only warn", err)
+			}
+		})
+	}
+}
+
+func TestDeleteIngressSafe(t *testing.T) {
+	builder := &IngressManager{}
+	shop := builder.BuildBasicIngress("storefront", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	shopCanary := builder.BuildBasicIngress("storefront-canary", "canary", "shop.orcapod.io", "/", "web-frontend-v2", 8080)
+	api := builder.BuildBasicIngress("storefront-api", "storefront", "api.orcapod.io", "/", "api-backend", 8080)
+
+	tests := []struct {
+		name     string
+		target   string
+		force    bool
+		wantErr  string
+		wantGone bool
+	}{
+		{name: "host still served elsewhere", target: "storefront", wantGone: true},
+		{name: "last ingress for host", target: "storefront-api", wantErr: "last ingress serving api.orcapod.io"},
+		{name: "forced", target: "storefront-api", force: true, wantGone: true},
+		{name: "missing ingress", target: "checkout", wantErr: "not found", wantGone: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := NewIngressManager(fake.NewSimpleClientset(shop, shopCanary, api))
+
+			err := m.DeleteIngressSafe(ctx, "storefront", tt.target, tt.force)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("DeleteIngressSafe() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("DeleteIngressSafe() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+			_, err = m.GetIngress(ctx, "storefront", tt.target)
+			if gone := apierrors.IsNotFound(err); gone != tt.wantGone {
+				t.Errorf("ingress gone = %v, want %v", gone, tt.wantGone)
+			}
+		})
+	}
+}
+
+func TestBuildRedirectIngress(t *testing.T) {
+	tests := []struct {
+		name      string
+		permanent bool
+		wantKey   string
+		otherKey  string
+	}{
+		{
+			name:      "permanent",
+			permanent: true,
+			wantKey:   "nginx.ingress.kubernetes.io/permanent-redirect",
+			otherKey:  "nginx.ingress.kubernetes.io/temporal-redirect",
+		},
+		{
+			name:     "temporary",
+			wantKey:  "nginx.ingress.kubernetes.io/temporal-redirect",
+			otherKey: "nginx.ingress.kubernetes.io/permanent-redirect",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			ingress := m.BuildRedirectIngress("apex", "storefront", "orcapod.io", "https://www.orcapod.io", tt.permanent)
+
+			if got := ingress.Annotations[tt.wantKey]; got != "https://www.orcapod.io" {
+				t.Errorf("%s = %q, want https://www.orcapod.io", tt.wantKey, got)
+			}
+			if _, ok := ingress.Annotations[tt.otherKey]; ok {
+				t.Errorf("%s is set on a %s redirect", tt.otherKey, tt.name)
+			}
+			if svc := ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service; svc.Name != RedirectPlaceholderService {
+				t.Errorf("backend = %s, want %s", svc.Name, RedirectPlaceholderService)
+			}
+			if err := ValidateIngress(ingress); err != nil {
+				t.Errorf("ValidateIngress() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestValidateDuplicateTLSHosts(t *testing.T) {
+	withTLS := func(name, namespace string, tls ...networkingv1.IngressTLS) networkingv1.Ingress {
+		m := &IngressManager{}
+		ingress := m.BuildBasicIngress(name, namespace, "shop.orcapod.io", "/", "web-frontend", 8080)
+		ingress.Spec.TLS = tls
+		return *ingress
+	}
+
+	t.Run("single ingress", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			tls     []networkingv1.IngressTLS
+			wantErr bool
+		}{
+			{
+				name: "same secret twice",
+				tls: []networkingv1.IngressTLS{
+					{Hosts: []string{"shop.orcapod.io"}, SecretName: "shop-tls"},
+					{Hosts: []string{"shop.orcapod.io"}, SecretName: "shop-tls"},
+				},
+			},
+			{
+				name: "two secrets",
+				tls: []networkingv1.IngressTLS{
+					{Hosts: []string{"shop.orcapod.io"}, SecretName: "shop-tls"},
+					{Hosts: []string{"shop.orcapod.io"}, SecretName: "shop-tls-2024"},
+				},
+				wantErr: true,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ingress := withTLS("web", "storefront", tt.tls...)
+				err := ValidateIngress(&ingress)
+				if !tt.wantErr {
+					if err != nil {
+						t.Errorf("ValidateIngress() = %v, want nil", err)
+					}
+					return
+				}
+				if !errors.Is(err, ErrDuplicateTLSHost) {
+					t.Fatalf("ValidateIngress() = %v, want %v", err, ErrDuplicateTLSHost)
+				}
+				for _, want := range []string{"shop.orcapod.io", "shop-tls", "shop-tls-2024"} {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("error %q does not mention %s", err, want)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("across ingresses", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			ingresses []networkingv1.Ingress
+			wantErrs  int
+		}{
+			{
+				name: "shared certificate",
+				ingresses: []networkingv1.Ingress{
+					withTLS("web", "storefront", networkingv1.IngressTLS{Hosts: []string{"shop.orcapod.io"}, SecretName: "shop-tls"}),
+					withTLS("api", "storefront", networkingv1.IngressTLS{Hosts: []string{"shop.orcapod.io"}, SecretName: "shop-tls"}),
+				},
+			},
+			{
+				name: "conflicting certificates",
+				ingresses: []networkingv1.Ingress{
+					withTLS("web", "storefront", networkingv1.IngressTLS{Hosts: []string{"shop.orcapod.io", "www.orcapod.io"}, SecretName: "shop-tls"}),
+					withTLS("api", "other", networkingv1.IngressTLS{Hosts: []string{"shop.orcapod.io", "www.orcapod.io"}, SecretName: "other-tls"}),
+				},
+				wantErrs: 2,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				errs := ValidateTLSHostsAcross(tt.ingresses)
+				if len(errs) != tt.wantErrs {
+					t.Fatalf("ValidateTLSHostsAcross() = %v, want %d errors", errs, tt.wantErrs)
+				}
+				for _, err := range errs {
+					if !errors.Is(err, ErrDuplicateTLSHost) || !strings.Contains(err.Error(), "other/api") {
+						t.Errorf("error %q is not a DuplicateTLSHost naming other/api", err)
+					}
+				}
+			})
+		}
+	})
+}
+
+func TestWatchIngresses(t *testing.T) {
+	saved := ingressWatchBackoff
+	ingressWatchBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 1}
+	defer func() { ingressWatchBackoff = saved }()
+
+	builder := &IngressManager{}
+	web := builder.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	web.ResourceVersion = "10"
+	updated := web.DeepCopy()
+	updated.ResourceVersion = "11"
+
+	type event struct {
+		Type watch.EventType
+		Name string
+	}
+	tests := []struct {
+		name string
+		// sessions are played in turn, one per Watch call; a nil session
+		// makes that call fail with ServiceUnavailable.
+		sessions    [][]watch.Event
+		wantEvents  []event
+		wantResumes []string
+	}{
+		{
+			name:        "add",
+			sessions:    [][]watch.Event{{{Type: watch.Added, Object: web}}},
+			wantEvents:  []event{{watch.Added, "web"}},
+			wantResumes: []string{""},
+		},
+		{
+			name: "resumes after close",
+			sessions: [][]watch.Event{
+				{{Type: watch.Added, Object: web}},
+				{{Type: watch.Modified, Object: updated}},
+			},
+			wantEvents:  []event{{watch.Added, "web"}, {watch.Modified, "web"}},
+			wantResumes: []string{"", "10"},
+		},
+		{
+			name: "retries transient errors",
+			sessions: [][]watch.Event{
+				nil,
+				{{Type: watch.Added, Object: web}},
+			},
+			wantEvents:  []event{{watch.Added, "web"}},
+			wantResumes: []string{"", ""},
+		},
+		{
+			name: "restarts when the version expired",
+			sessions: [][]watch.Event{
+				{{Type: watch.Added, Object: web}},
+				{{Type: watch.Error, Object: &apierrors.NewResourceExpired("too old").ErrStatus}},
+				{{Type: watch.Added, Object: updated}},
+			},
+			wantEvents:  []event{{watch.Added, "web"}, {watch.Added, "web"}},
+			wantResumes: []string{"", "10", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			clientset := fake.NewSimpleClientset()
+			var resumes []string
+			clientset.PrependWatchReactor("ingresses", func(action k8stesting.Action) (bool, watch.Interface, error) {
+				resumes = append(resumes, action.(k8stesting.WatchActionImpl).GetWatchRestrictions().ResourceVersion)
+				n := len(resumes) - 1
+				if n >= len(tt.sessions) {
+					// Nothing left to play: stop the test once watched again.
+					cancel()
+					return true, watch.NewFake(), nil
+				}
+				if tt.sessions[n] == nil {
+					return true, nil, apierrors.NewServiceUnavailable("apiserver restarting")
+				}
+				w := watch.NewFakeWithChanSize(len(tt.sessions[n]), false)
+				for _, e := range tt.sessions[n] {
+					w.Action(e.Type, e.Object)
+				}
+				w.Stop()
+				return true, w, nil
+			})
+
+			var got []event
+			err := NewIngressManager(clientset).WatchIngresses(ctx, "storefront", func(eventType watch.EventType, ing *networkingv1.Ingress) {
+				got = append(got, event{eventType, ing.Name})
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("WatchIngresses() error = %v, want %v", err, context.Canceled)
+			}
+			if !reflect.DeepEqual(got, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", got, tt.wantEvents)
+			}
+			if resumes = resumes[:len(resumes)-1]; !reflect.DeepEqual(resumes, tt.wantResumes) {
+				t.Errorf("watch resourceVersions = %q, want %q", resumes, tt.wantResumes)
+			}
+		})
+	}
+
+	t.Run("permanent error", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset()
+		clientset.PrependWatchReactor("ingresses", func(k8stesting.Action) (bool, watch.Interface, error) {
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}, "", errors.New("denied"))
+		})
+		err := NewIngressManager(clientset).WatchIngresses(context.Background(), "storefront", func(watch.EventType, *networkingv1.Ingress) {})
+		if !apierrors.IsForbidden(err) {
+			t.Errorf("WatchIngresses() error = %v, want Forbidden", err)
+		}
+	})
+}
+
+func TestValidateForProvision(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		wantErr error
+	}{
+		{name: "valid", service: "api-backend"},
+		{name: "invalid", service: "api_backend", wantErr: ErrInvalidServiceName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			m := NewIngressManager(clientset)
+			ingress := m.BuildBasicIngress("storefront-api", "storefront", "api.orcapod.io", "/", tt.service, 8080)
+
+			err := m.validateForProvision(ingress)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("validateForProvision() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), "storefront-api") {
+				t.Errorf("validateForProvision() = %v, want %v naming storefront-api", err, tt.wantErr)
+			}
+			if len(clientset.Actions()) != 0 {
+				t.Errorf("validation made API calls: %v", clientset.Actions())
+			}
+		})
+	}
+}
+
+func TestUpdateIngressDryRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		update     func(*IngressManager, context.Context, *networkingv1.Ingress) (*networkingv1.Ingress, error)
+		wantDryRun []string
+	}{
+		{name: "dry run", update: (*IngressManager).UpdateIngressDryRun, wantDryRun: []string{metav1.DryRunAll}},
+		{name: "real update", update: (*IngressManager).UpdateIngress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			builder := &IngressManager{}
+			clientset := fake.NewSimpleClientset(builder.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080))
+			m := NewIngressManager(clientset)
+
+			ingress, err := m.GetIngress(ctx, "storefront", "web")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ingress.Annotations["nginx.ingress.kubernetes.io/limit-rps"] = "50"
+			got, err := tt.update(m, ctx, ingress)
+			if err != nil {
+				t.Fatalf("update error = %v", err)
+			}
+			if got.Annotations["nginx.ingress.kubernetes.io/limit-rps"] != "50" {
+				t.Errorf("returned ingress annotations = %v, want the change", got.Annotations)
+			}
+
+			var opts []metav1.UpdateOptions
+			for _, action := range clientset.Actions() {
+				if update, ok := action.(k8stesting.UpdateActionImpl); ok {
+					opts = append(opts, update.GetUpdateOptions())
+				}
+			}
+			if len(opts) != 1 || !reflect.DeepEqual(opts[0].DryRun, tt.wantDryRun) {
+				t.Errorf("update options = %+v, want DryRun %v", opts, tt.wantDryRun)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// provisionerMetrics holds the Prometheus collectors for an IngressManager. A
+// nil *provisionerMetrics records nothing, so managers without a registry pay
+// no cost beyond a nil check.
+type provisionerMetrics struct {
+	ingresses *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+}
+
+// MustRegister enables metrics on the manager and registers its collectors
+// with reg. It panics if the collectors are already registered.
+func (m *IngressManager) MustRegister(reg *prometheus.Registry) {
+	pm := &provisionerMetrics{
+		ingresses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "ingress_provisioner",
+			Name:      "ingresses_total",
+			Help:      "Number of Ingress resources changed, by operation.",
+		}, []string{"operation"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "ingress_provisioner",
+			Name:      "api_request_duration_seconds",
+			Help:      "Latency of Kubernetes API calls made for Ingress resources, by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+	reg.MustRegister(pm.ingresses, pm.latency)
+	m.metrics = pm
+}
+
+// start returns the time an API call began, or the zero time when metrics are
+// disabled.
+func (pm *provisionerMetrics) start() time.Time {
+	if pm == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// observe records the latency of an API call started at start.
+func (pm *provisionerMetrics) observe(method string, start time.Time) {
+	if pm == nil {
+		return
+	}
+	pm.latency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// count increments the ingress counter for a successful operation.
+func (pm *provisionerMetrics) count(operation string) {
+	if pm == nil {
+		return
+	}
+	pm.ingresses.WithLabelValues(operation).Inc()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMetrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation string
+		run       func(context.Context, *IngressManager) error
+	}{
+		{
+			name:      "create",
+			operation: "created",
+			run: func(ctx context.Context, m *IngressManager) error {
+				_, err := m.CreateIngress(ctx, m.BuildBasicIngress("new", "storefront", "new.orcapod.io", "/", "web-frontend", 8080))
+				return err
+			},
+		},
+		{
+			name:      "update",
+			operation: "updated",
+			run: func(ctx context.Context, m *IngressManager) error {
+				_, err := m.UpdateIngress(ctx, m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/v2", "web-frontend", 8080))
+				return err
+			},
+		},
+		{
+			name:      "delete",
+			operation: "deleted",
+			run: func(ctx context.Context, m *IngressManager) error {
+				return m.DeleteIngress(ctx, "storefront", "web")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := (&IngressManager{}).BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+			m := NewIngressManager(fake.NewSimpleClientset(existing))
+			reg := prometheus.NewRegistry()
+			m.MustRegister(reg)
+
+			if err := tt.run(context.Background(), m); err != nil {
+				t.Fatalf("%s error = %v", tt.name, err)
+			}
+			if got := testutil.ToFloat64(m.metrics.ingresses.WithLabelValues(tt.operation)); got != 1 {
+				t.Errorf("ingresses_total{operation=%q} = %v, want 1", tt.operation, got)
+			}
+			if got := testutil.CollectAndCount(m.metrics.latency); got != 1 {
+				t.Errorf("latency series = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestMetricsFailedCreateNotCounted(t *testing.T) {
+	existing := (&IngressManager{}).BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	m := NewIngressManager(fake.NewSimpleClientset(existing))
+	m.MustRegister(prometheus.NewRegistry())
+
+	if _, err := m.CreateIngress(context.Background(), existing.DeepCopy()); err == nil {
+		t.Fatal("CreateIngress() of an existing ingress succeeded")
+	}
+	if got := testutil.ToFloat64(m.metrics.ingresses.WithLabelValues("created")); got != 0 {
+		t.Errorf("ingresses_total{operation=\"created\"} = %v, want 0", got)
+	}
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	m := NewIngressManager(fake.NewSimpleClientset())
+	if _, err := m.CreateIngress(context.Background(), m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)); err != nil {
+		t.Fatalf("CreateIngress() without metrics error = %v", err)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// DefaultGatewayProgrammedTimeout bounds how long ApplyMigrationResult waits
+// for the Gateway to report Programmed before creating HTTPRoutes, unless
+// SetGatewayProgrammedTimeout says otherwise.
+const DefaultGatewayProgrammedTimeout = 2 * time.Minute
+
+// SetGatewayProgrammedTimeout changes how long ApplyMigrationResult waits for
+// the Gateway to report Programmed. Zero or less restores
+// DefaultGatewayProgrammedTimeout.
+func (m *IngressManager) SetGatewayProgrammedTimeout(timeout time.Duration) {
+	m.gatewayProgrammedTimeout = timeout
+}
+
+// MigrationResult holds the Gateway API objects that replace a set of
+// Ingresses. Sources maps each HTTPRoute to the Ingress it was converted from.
+type MigrationResult struct {
+	ReferenceGrants []*gatewayv1beta1.ReferenceGrant
+	Gateway         *gatewayv1.Gateway
+	HTTPRoutes      []*gatewayv1.HTTPRoute
+	Sources         map[types.NamespacedName]types.NamespacedName
+}
+
+// AppliedObject identifies an object created while applying a MigrationResult.
+type AppliedObject struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (o AppliedObject) String() string {
+	return fmt.Sprintf("%s %s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+// ApplyError reports a failed ApplyMigrationResult along with the objects that
+// were created before the failure, so they can be cleaned up.
+type ApplyError struct {
+	Created []AppliedObject
+	Err     error
+}
+
+func (e *ApplyError) Error() string {
+	return fmt.Sprintf("apply migration result: %v (created %d objects before failure)", e.Err, len(e.Created))
+}
+
+func (e *ApplyError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyMigrationResult creates the objects in a MigrationResult in dependency
+// order: ReferenceGrants, then the Gateway, then (once the Gateway is
+// Programmed) the HTTPRoutes. On failure it returns an *ApplyError listing
+// what was already created.
+func (m *IngressManager) ApplyMigrationResult(ctx context.Context, result *MigrationResult) error {
+	var created []AppliedObject
+	fail := func(err error) error {
+		return &ApplyError{Created: created, Err: err}
+	}
+
+	for _, grant := range result.ReferenceGrants {
+		if _, err := m.CreateReferenceGrant(ctx, grant); err != nil {
+			return fail(fmt.Errorf("failed to create ReferenceGrant %s/%s: %w", grant.Namespace, grant.Name, err))
+		}
+		created = append(created, AppliedObject{Kind: "ReferenceGrant", Namespace: grant.Namespace, Name: grant.Name})
+	}
+
+	if result.Gateway != nil {
+		gw := result.Gateway
+		if _, err := m.CreateGateway(ctx, gw); err != nil {
+			return fail(fmt.Errorf("failed to create Gateway %s/%s: %w", gw.Namespace, gw.Name, err))
+		}
+		created = append(created, AppliedObject{Kind: "Gateway", Namespace: gw.Namespace, Name: gw.Name})
+
+		if err := m.waitForGatewayProgrammed(ctx, gw.Namespace, gw.Name); err != nil {
+			return fail(fmt.Errorf("Gateway %s/%s not programmed: %w", gw.Namespace, gw.Name, err))
+		}
+	}
+
+	for _, route := range result.HTTPRoutes {
+		if _, err := m.CreateHTTPRoute(ctx, route); err != nil {
+			return fail(fmt.Errorf("failed to create HTTPRoute %s/%s: %w", route.Namespace, route.Name, err))
+		}
+		created = append(created, AppliedObject{Kind: "HTTPRoute", Namespace: route.Namespace, Name: route.Name})
+	}
+
+	return nil
+}
+
+// waitForGatewayProgrammed polls a Gateway until its Programmed condition is
+// true or the manager's Gateway timeout elapses. Get errors are handled by
+// stopPolling.
+func (m *IngressManager) waitForGatewayProgrammed(ctx context.Context, namespace, name string) error {
+	timeout := m.gatewayProgrammedTimeout
+	if timeout <= 0 {
+		timeout = DefaultGatewayProgrammedTimeout
+	}
+	return wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		gw, err := m.gwclient.GatewayV1().Gateways(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, stopPolling(err)
+		}
+		return meta.IsStatusConditionTrue(gw.Status.Conditions, string(gatewayv1.GatewayConditionProgrammed)), nil
+	})
+}
+
+// PruneMigratedIngresses deletes the source Ingresses of a MigrationResult
+// whose HTTPRoutes are all Accepted by every parent Gateway, returning the
+// "namespace/name" of each Ingress deleted. With confirm=false nothing is
+// deleted and the Ingresses that would be are returned instead. Ingresses with
+// a route that is not yet Accepted are never pruned; they are listed in the
+// returned error.
+func (m *IngressManager) PruneMigratedIngresses(ctx context.Context, result *MigrationResult, confirm bool) ([]string, error) {
+	var order []types.NamespacedName
+	ready := make(map[types.NamespacedName]bool)
+	for _, route := range result.HTTPRoutes {
+		routeKey := types.NamespacedName{Namespace: route.Namespace, Name: route.Name}
+		src, ok := result.Sources[routeKey]
+		if !ok {
+			continue
+		}
+		accepted, err := m.httpRouteAccepted(ctx, route.Namespace, route.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check HTTPRoute %s: %w", routeKey, err)
+		}
+		if _, seen := ready[src]; !seen {
+			order = append(order, src)
+			ready[src] = accepted
+		} else {
+			ready[src] = ready[src] && accepted
+		}
+	}
+
+	var pruned, refused []string
+	for _, src := range order {
+		if !ready[src] {
+			refused = append(refused, src.String())
+			continue
+		}
+		if confirm {
+			if err := m.DeleteIngress(ctx, src.Namespace, src.Name); err != nil {
+				return pruned, fmt.Errorf("failed to delete ingress %s: %w", src, err)
+			}
+		}
+		pruned = append(pruned, src.String())
+	}
+
+	if len(refused) > 0 {
+		return pruned, fmt.Errorf("refusing to prune ingresses whose HTTPRoutes are not Accepted: %s", strings.Join(refused, ", "))
+	}
+	return pruned, nil
+}
+
+// httpRouteAccepted reports whether an HTTPRoute has at least one parent and is
+// Accepted by all of them.
+func (m *IngressManager) httpRouteAccepted(ctx context.Context, namespace, name string) (bool, error) {
+	statuses, err := m.GetHTTPRouteStatus(ctx, namespace, name)
+	if err != nil {
+		return false, err
+	}
+	if len(statuses) == 0 {
+		return false, nil
+	}
+	for _, st := range statuses {
+		if !st.Accepted {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// SetRecordCopyProvenance makes CopyIngress call RecordProvenance on each
+// copy it creates. It is off by default.
+func (m *IngressManager) SetRecordCopyProvenance(enabled bool) {
+	m.recordCopyProvenance = enabled
+}
+
+// Provenance annotations written by RecordProvenance.
+const (
+	OriginalUIDAnnotation     = "migration.orcapod.io/original-uid"
+	OriginalCreatedAnnotation = "migration.orcapod.io/original-created"
+)
+
+// RecordProvenance annotates dst with the UID and creationTimestamp (RFC 3339)
+// of src so a copied or migrated object can be traced back to its source. If
+// src itself carries provenance annotations, those are kept instead, so a copy
+// of a copy still points at the first object.
+func RecordProvenance(dst, src metav1.Object) {
+	annotations := make(map[string]string, len(dst.GetAnnotations())+2)
+	for k, v := range dst.GetAnnotations() {
+		annotations[k] = v
+	}
+
+	srcAnnotations := src.GetAnnotations()
+	if uid, ok := srcAnnotations[OriginalUIDAnnotation]; ok {
+		annotations[OriginalUIDAnnotation] = uid
+		annotations[OriginalCreatedAnnotation] = srcAnnotations[OriginalCreatedAnnotation]
+	} else {
+		annotations[OriginalUIDAnnotation] = string(src.GetUID())
+		annotations[OriginalCreatedAnnotation] = src.GetCreationTimestamp().UTC().Format(time.RFC3339)
+	}
+	dst.SetAnnotations(annotations)
+}
+
+// MigrationPhaseLabel records which migration wave stage an Ingress is in.
+const MigrationPhaseLabel = "migration.orcapod.io/phase"
+
+// Migration phases an Ingress moves through.
+const (
+	MigrationPhasePending   = "pending"
+	MigrationPhaseConverted = "converted"
+	MigrationPhaseVerified  = "verified"
+	MigrationPhasePruned    = "pruned"
+)
+
+var migrationPhases = map[string]bool{
+	MigrationPhasePending:   true,
+	MigrationPhaseConverted: true,
+	MigrationPhaseVerified:  true,
+	MigrationPhasePruned:    true,
+}
+
+// SetMigrationPhase sets the migration phase label on an Ingress with a
+// strategic merge patch, leaving all other fields untouched.
+func (m *IngressManager) SetMigrationPhase(ctx context.Context, namespace, name, phase string) error {
+	if !migrationPhases[phase] {
+		return fmt.Errorf("unknown migration phase %q", phase)
+	}
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"labels": map[string]string{MigrationPhaseLabel: phase},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.clientset.NetworkingV1().Ingresses(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to set migration phase on ingress %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
+
+// ListByMigrationPhase lists Ingresses in all namespaces carrying the given
+// migration phase label.
+func (m *IngressManager) ListByMigrationPhase(ctx context.Context, phase string) ([]networkingv1.Ingress, error) {
+	if !migrationPhases[phase] {
+		return nil, fmt.Errorf("unknown migration phase %q", phase)
+	}
+	list, err := m.clientset.NetworkingV1().Ingresses(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{MigrationPhaseLabel: phase}).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses in migration phase %s: %w", phase, err)
+	}
+	return list.Items, nil
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+	gwfake "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned/fake"
+)
+
+// testMigrationResult is a MigrationResult with one of each object kind.
+func testMigrationResult() *MigrationResult {
+	return &MigrationResult{
+		ReferenceGrants: []*gatewayv1beta1.ReferenceGrant{
+			{ObjectMeta: metav1.ObjectMeta{Name: "certs", Namespace: "storefront"}},
+		},
+		Gateway: &gatewayv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "public", Namespace: "gateway"}},
+		HTTPRoutes: []*gatewayv1.HTTPRoute{
+			{ObjectMeta: metav1.ObjectMeta{Name: "storefront", Namespace: "storefront"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "storefront-api", Namespace: "storefront"}},
+		},
+	}
+}
+
+// programGatewaysOnCreate makes every Gateway created through c report
+// Programmed=True, as a controller would.
+func programGatewaysOnCreate(c *k8stesting.Fake) {
+	c.PrependReactor("create", "gateways", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gw := action.(k8stesting.CreateAction).GetObject().(*gatewayv1.Gateway)
+		gw.Status.Conditions = []metav1.Condition{{
+			Type:   string(gatewayv1.GatewayConditionProgrammed),
+			Status: metav1.ConditionTrue,
+			Reason: "Programmed",
+		}}
+		return false, nil, nil
+	})
+}
+
+func TestApplyMigrationResult(t *testing.T) {
+	tests := []struct {
+		name         string
+		failResource string
+		programmed   bool
+		wantCreates  []string
+		wantCreated  []AppliedObject
+		wantErr      bool
+	}{
+		{
+			name:        "dependency order",
+			programmed:  true,
+			wantCreates: []string{"referencegrants", "gateways", "httproutes", "httproutes"},
+		},
+		{
+			name:         "route create fails",
+			programmed:   true,
+			failResource: "httproutes",
+			wantCreates:  []string{"referencegrants", "gateways", "httproutes"},
+			wantCreated: []AppliedObject{
+				{Kind: "ReferenceGrant", Namespace: "storefront", Name: "certs"},
+				{Kind: "Gateway", Namespace: "gateway", Name: "public"},
+			},
+			wantErr: true,
+		},
+		{
+			name:        "gateway never programmed",
+			wantCreates: []string{"referencegrants", "gateways"},
+			wantCreated: []AppliedObject{
+				{Kind: "ReferenceGrant", Namespace: "storefront", Name: "certs"},
+				{Kind: "Gateway", Namespace: "gateway", Name: "public"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, gwclient := newGatewayTestManager()
+			m.SetGatewayProgrammedTimeout(50 * time.Millisecond)
+			if tt.programmed {
+				programGatewaysOnCreate(&gwclient.Fake)
+			}
+			if tt.failResource != "" {
+				gwclient.PrependReactor("create", tt.failResource, func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: tt.failResource}, "", errors.New("denied"))
+				})
+			}
+
+			err := m.ApplyMigrationResult(context.Background(), testMigrationResult())
+
+			var creates []string
+			for _, action := range gwclient.Actions() {
+				if action.GetVerb() == "create" {
+					creates = append(creates, action.GetResource().Resource)
+				}
+			}
+			if !reflect.DeepEqual(creates, tt.wantCreates) {
+				t.Errorf("create order = %v, want %v", creates, tt.wantCreates)
+			}
+
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("ApplyMigrationResult() error = %v", err)
+				}
+				return
+			}
+			var applyErr *ApplyError
+			if !errors.As(err, &applyErr) {
+				t.Fatalf("ApplyMigrationResult() error = %v, want *ApplyError", err)
+			}
+			if !reflect.DeepEqual(applyErr.Created, tt.wantCreated) {
+				t.Errorf("Created = %v, want %v", applyErr.Created, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestPruneMigratedIngresses(t *testing.T) {
+	acceptedRoute := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "storefront", Namespace: "storefront"},
+		Status: gatewayv1.HTTPRouteStatus{RouteStatus: gatewayv1.RouteStatus{
+			Parents: []gatewayv1.RouteParentStatus{routeParentStatus("public", metav1.ConditionTrue)},
+		}},
+	}
+	pendingRoute := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "storefront-api", Namespace: "storefront"},
+		Status: gatewayv1.HTTPRouteStatus{RouteStatus: gatewayv1.RouteStatus{
+			Parents: []gatewayv1.RouteParentStatus{routeParentStatus("public", metav1.ConditionFalse)},
+		}},
+	}
+	result := &MigrationResult{
+		HTTPRoutes: []*gatewayv1.HTTPRoute{acceptedRoute, pendingRoute},
+		Sources: map[types.NamespacedName]types.NamespacedName{
+			{Namespace: "storefront", Name: "storefront"}:     {Namespace: "storefront", Name: "storefront"},
+			{Namespace: "storefront", Name: "storefront-api"}: {Namespace: "storefront", Name: "storefront-api"},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		confirm       bool
+		wantRemaining []string
+	}{
+		{name: "dry run", confirm: false, wantRemaining: []string{"storefront", "storefront-api"}},
+		{name: "confirmed", confirm: true, wantRemaining: []string{"storefront-api"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := &IngressManager{}
+			clientset := fake.NewSimpleClientset(
+				m.BuildBasicIngress("storefront", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080),
+				m.BuildBasicIngress("storefront-api", "storefront", "api.orcapod.io", "/", "api-backend", 8080),
+			)
+			m = NewIngressManagerWithGateway(clientset, gwfake.NewSimpleClientset(acceptedRoute, pendingRoute))
+
+			pruned, err := m.PruneMigratedIngresses(ctx, result, tt.confirm)
+			if err == nil {
+				t.Error("PruneMigratedIngresses() error = nil, want a refusal for storefront/storefront-api")
+			}
+			if want := []string{"storefront/storefront"}; !reflect.DeepEqual(pruned, want) {
+				t.Errorf("pruned = %v, want %v", pruned, want)
+			}
+
+			list, err := clientset.NetworkingV1().Ingresses("storefront").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var remaining []string
+			for _, ing := range list.Items {
+				remaining = append(remaining, ing.Name)
+			}
+			sort.Strings(remaining)
+			if !reflect.DeepEqual(remaining, tt.wantRemaining) {
+				t.Errorf("remaining ingresses = %v, want %v", remaining, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestMigrationPhase(t *testing.T) {
+	ctx := context.Background()
+	builder := &IngressManager{}
+	storefront := builder.BuildBasicIngress("storefront", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	api := builder.BuildBasicIngress("storefront-api", "storefront", "api.orcapod.io", "/", "api-backend", 8080)
+	admin := builder.BuildBasicIngress("dashboard", "admin", "admin.orcapod.io", "/", "dashboard", 8080)
+	admin.Labels = map[string]string{"team": "platform"}
+	m := NewIngressManager(fake.NewSimpleClientset(storefront, api, admin))
+
+	for _, set := range []struct{ namespace, name, phase string }{
+		{"storefront", "storefront", MigrationPhaseConverted},
+		{"admin", "dashboard", MigrationPhaseConverted},
+		{"storefront", "storefront-api", MigrationPhasePending},
+	} {
+		if err := m.SetMigrationPhase(ctx, set.namespace, set.name, set.phase); err != nil {
+			t.Fatalf("SetMigrationPhase(%s/%s, %s) error = %v", set.namespace, set.name, set.phase, err)
+		}
+	}
+
+	tests := []struct {
+		phase string
+		want  []string
+	}{
+		{phase: MigrationPhaseConverted, want: []string{"admin/dashboard", "storefront/storefront"}},
+		{phase: MigrationPhasePending, want: []string{"storefront/storefront-api"}},
+		{phase: MigrationPhaseVerified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.phase, func(t *testing.T) {
+			ingresses, err := m.ListByMigrationPhase(ctx, tt.phase)
+			if err != nil {
+				t.Fatalf("ListByMigrationPhase() error = %v", err)
+			}
+			var got []string
+			for _, ing := range ingresses {
+				got = append(got, ing.Namespace+"/"+ing.Name)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListByMigrationPhase(%s) = %v, want %v", tt.phase, got, tt.want)
+			}
+		})
+	}
+
+	dashboard, err := m.GetIngress(ctx, "admin", "dashboard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dashboard.Labels["team"] != "platform" {
+		t.Errorf("SetMigrationPhase dropped existing labels: %v", dashboard.Labels)
+	}
+	if err := m.SetMigrationPhase(ctx, "admin", "dashboard", "done"); err == nil {
+		t.Error("SetMigrationPhase() accepted unknown phase \"done\"")
+	}
+
+	for _, phase := range []string{"", "done", "pending,team=platform", "pending team"} {
+		if _, err := m.ListByMigrationPhase(ctx, phase); err == nil {
+			t.Errorf("ListByMigrationPhase() accepted unknown phase %q", phase)
+		}
+	}
+
+	t.Run("list error", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset()
+		clientset.PrependReactor("list", "ingresses", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewServiceUnavailable("etcd unavailable")
+		})
+		_, err := NewIngressManager(clientset).ListByMigrationPhase(ctx, MigrationPhasePending)
+		if !apierrors.IsServiceUnavailable(err) {
+			t.Errorf("ListByMigrationPhase() error = %v, want the wrapped ServiceUnavailable", err)
+		}
+	})
+}
+
+func TestRecordProvenance(t *testing.T) {
+	created := metav1.NewTime(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	source := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{
+		Name:              "web",
+		Namespace:         "storefront",
+		UID:               "1234",
+		CreationTimestamp: created,
+	}}
+	copied := source.DeepCopy()
+	copied.UID = "5678"
+	copied.Annotations = map[string]string{
+		OriginalUIDAnnotation:     "1234",
+		OriginalCreatedAnnotation: "2024-03-01T12:00:00Z",
+	}
+
+	tests := []struct {
+		name string
+		src  *networkingv1.Ingress
+	}{
+		{name: "from the original", src: source},
+		{name: "from a copy", src: copied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{"nginx.ingress.kubernetes.io/ssl-redirect": "true"},
+			}}
+			RecordProvenance(dst, tt.src)
+
+			want := map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect": "true",
+				OriginalUIDAnnotation:                      "1234",
+				OriginalCreatedAnnotation:                  "2024-03-01T12:00:00Z",
+			}
+			if !reflect.DeepEqual(dst.Annotations, want) {
+				t.Errorf("annotations = %v, want %v", dst.Annotations, want)
+			}
+		})
+	}
+
+	t.Run("CopyIngress", func(t *testing.T) {
+		ctx := context.Background()
+		m := NewIngressManager(fake.NewSimpleClientset(source.DeepCopy()))
+		m.SetRecordCopyProvenance(true)
+
+		dst, _, err := m.CopyIngress(ctx, "storefront", "web", "storefront-blue", nil)
+		if err != nil {
+			t.Fatalf("CopyIngress() error = %v", err)
+		}
+		if dst.Annotations[OriginalUIDAnnotation] != "1234" {
+			t.Errorf("copy annotations = %v, want %s=1234", dst.Annotations, OriginalUIDAnnotation)
+		}
+		if _, ok := source.Annotations[OriginalUIDAnnotation]; ok {
+			t.Error("CopyIngress annotated the source")
+		}
+	})
+}
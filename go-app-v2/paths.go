@@ -0,0 +1,62 @@
+package main
+
+import (
+	"regexp"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// PathMatch is one host/path pair an Ingress routes. For ImplementationSpecific
+// paths that ingress-nginx matches as regexes, Regex is set and Captures and
+// CaptureNames describe the capture groups a rewrite-target can refer to.
+type PathMatch struct {
+	Host string
+	Path string
+	Type networkingv1.PathType
+
+	Regex        bool
+	Captures     int
+	CaptureNames []string
+}
+
+// ExtractPathMatches lists every path an Ingress routes, in spec order. A path
+// is treated as a regex when it is ImplementationSpecific and the ingress sets
+// use-regex or rewrite-target, as ingress-nginx does. A regex that does not
+// compile is reported with Regex set and no capture info; AdmissionCheck
+// reports the compile error.
+func ExtractPathMatches(ingress *networkingv1.Ingress) []PathMatch {
+	_, rewrite := ingress.Annotations["nginx.ingress.kubernetes.io/rewrite-target"]
+	useRegex, _ := nginxBoolAnnotation(ingress.Annotations, "nginx.ingress.kubernetes.io/use-regex")
+	regexEnabled := rewrite || useRegex
+
+	var matches []PathMatch
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			pm := PathMatch{
+				Host: rule.Host,
+				Path: path.Path,
+				Type: networkingv1.PathTypeImplementationSpecific,
+			}
+			if path.PathType != nil {
+				pm.Type = *path.PathType
+			}
+
+			if regexEnabled && pm.Type == networkingv1.PathTypeImplementationSpecific {
+				pm.Regex = true
+				if re, err := regexp.Compile(path.Path); err == nil {
+					pm.Captures = re.NumSubexp()
+					for _, name := range re.SubexpNames() {
+						if name != "" {
+							pm.CaptureNames = append(pm.CaptureNames, name)
+						}
+					}
+				}
+			}
+			matches = append(matches, pm)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func TestExtractPathMatches(t *testing.T) {
+	m := &IngressManager{}
+	implementationSpecific := networkingv1.PathTypeImplementationSpecific
+
+	basic := m.BuildBasicIngress("web", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+
+	regex := m.BuildBasicIngress("api", "storefront", "api.orcapod.io", "/api/(?P<version>v[0-9]+)/(.*)", "api-backend", 8080)
+	regex.Annotations["nginx.ingress.kubernetes.io/rewrite-target"] = "/$2"
+	regex.Spec.Rules[0].HTTP.Paths[0].PathType = &implementationSpecific
+	health := *regex.Spec.Rules[0].HTTP.Paths[0].DeepCopy()
+	health.Path = "/healthz"
+	health.PathType = nil
+	regex.Spec.Rules[0].HTTP.Paths = append(regex.Spec.Rules[0].HTTP.Paths, health)
+
+	noRegex := m.BuildBasicIngress("docs", "storefront", "docs.orcapod.io", "/docs/(.*)", "docs", 8080)
+	noRegex.Spec.Rules[0].HTTP.Paths[0].PathType = &implementationSpecific
+
+	useRegexTrue := noRegex.DeepCopy()
+	useRegexTrue.Annotations["nginx.ingress.kubernetes.io/use-regex"] = "True"
+	useRegexOne := noRegex.DeepCopy()
+	useRegexOne.Annotations["nginx.ingress.kubernetes.io/use-regex"] = "1"
+	useRegexOff := noRegex.DeepCopy()
+	useRegexOff.Annotations["nginx.ingress.kubernetes.io/use-regex"] = "false"
+	docsRegex := []PathMatch{{Host: "docs.orcapod.io", Path: "/docs/(.*)", Type: networkingv1.PathTypeImplementationSpecific, Regex: true, Captures: 1}}
+
+	tests := []struct {
+		name    string
+		ingress *networkingv1.Ingress
+		want    []PathMatch
+	}{
+		{
+			name:    "prefix",
+			ingress: basic,
+			want:    []PathMatch{{Host: "shop.orcapod.io", Path: "/", Type: networkingv1.PathTypePrefix}},
+		},
+		{
+			name:    "regex with rewrite-target",
+			ingress: regex,
+			want: []PathMatch{
+				{
+					Host:         "api.orcapod.io",
+					Path:         "/api/(?P<version>v[0-9]+)/(.*)",
+					Type:         networkingv1.PathTypeImplementationSpecific,
+					Regex:        true,
+					Captures:     2,
+					CaptureNames: []string{"version"},
+				},
+				{Host: "api.orcapod.io", Path: "/healthz", Type: networkingv1.PathTypeImplementationSpecific, Regex: true},
+			},
+		},
+		{
+			name:    "regex not enabled",
+			ingress: noRegex,
+			want:    []PathMatch{{Host: "docs.orcapod.io", Path: "/docs/(.*)", Type: networkingv1.PathTypeImplementationSpecific}},
+		},
+		{name: "use-regex True", ingress: useRegexTrue, want: docsRegex},
+		{name: "use-regex 1", ingress: useRegexOne, want: docsRegex},
+		{
+			name:    "use-regex false",
+			ingress: useRegexOff,
+			want:    []PathMatch{{Host: "docs.orcapod.io", Path: "/docs/(.*)", Type: networkingv1.PathTypeImplementationSpecific}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractPathMatches(tt.ingress); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractPathMatches() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
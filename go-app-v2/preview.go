@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// RenderNginxPreview summarizes the behavior ingress-nginx gives an Ingress as
+// pseudo-nginx server and location blocks: TLS listeners, HTTPS redirects,
+// rewrites, proxy timeouts, rate limits and snippet headers. It is meant for
+// reviewers and is not a config the controller would actually generate.
+func RenderNginxPreview(ingress *networkingv1.Ingress) string {
+	ann := ingress.Annotations
+	var b strings.Builder
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		serverName := rule.Host
+		if serverName == "" {
+			serverName = "_"
+		}
+
+		b.WriteString("server {\n")
+		b.WriteString("    listen 80;\n")
+		tls := tlsForHost(ingress.Spec.TLS, rule.Host)
+		if len(tls) > 0 {
+			fmt.Fprintf(&b, "    listen 443 ssl;  # certificate from secret %s\n", tls[0].SecretName)
+		}
+		fmt.Fprintf(&b, "    server_name %s;\n", serverName)
+		// ssl-redirect defaults to true whenever the host has TLS.
+		sslRedirect, ok := nginxBoolAnnotation(ann, "nginx.ingress.kubernetes.io/ssl-redirect")
+		if !ok {
+			sslRedirect = true
+		}
+		forceSSLRedirect, _ := nginxBoolAnnotation(ann, "nginx.ingress.kubernetes.io/force-ssl-redirect")
+		if len(tls) > 0 && sslRedirect || forceSSLRedirect {
+			b.WriteString("    # plain HTTP requests are redirected to https://$host$request_uri (308)\n")
+		}
+		writeSnippetLines(&b, "    ", ann["nginx.ingress.kubernetes.io/server-snippet"])
+
+		for _, path := range rule.HTTP.Paths {
+			b.WriteString("\n")
+			fmt.Fprintf(&b, "    location %s {\n", previewLocation(ingress, path))
+			if svc := path.Backend.Service; svc != nil {
+				fmt.Fprintf(&b, "        proxy_pass http://%s.%s:%s;\n", svc.Name, ingress.Namespace, servicePortString(svc.Port))
+			}
+			if target, ok := ann["nginx.ingress.kubernetes.io/rewrite-target"]; ok {
+				fmt.Fprintf(&b, "        rewrite \"(?i)%s\" %s break;\n", path.Path, target)
+			}
+			for _, key := range proxyTimeoutAnnotations {
+				if v, ok := ann[key]; ok {
+					directive := strings.ReplaceAll(strings.TrimPrefix(key, "nginx.ingress.kubernetes.io/"), "-", "_")
+					fmt.Fprintf(&b, "        %s %ss;\n", directive, v)
+				}
+			}
+			if v, ok := ann["nginx.ingress.kubernetes.io/limit-rps"]; ok {
+				fmt.Fprintf(&b, "        limit_req rate=%sr/s;\n", v)
+			}
+			if v, ok := ann["nginx.ingress.kubernetes.io/limit-rpm"]; ok {
+				fmt.Fprintf(&b, "        limit_req rate=%sr/m;\n", v)
+			}
+			if v, ok := ann["nginx.ingress.kubernetes.io/limit-connections"]; ok {
+				fmt.Fprintf(&b, "        limit_conn %s;\n", v)
+			}
+			writeSnippetLines(&b, "        ", ann["nginx.ingress.kubernetes.io/configuration-snippet"])
+			b.WriteString("    }\n")
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// previewLocation renders the nginx location modifier and path for an Ingress
+// path: "= /x" for Exact, "~* /x" when the path is matched as a regex and the
+// bare path for Prefix.
+func previewLocation(ingress *networkingv1.Ingress, path networkingv1.HTTPIngressPath) string {
+	_, rewrite := ingress.Annotations["nginx.ingress.kubernetes.io/rewrite-target"]
+	useRegex, _ := nginxBoolAnnotation(ingress.Annotations, "nginx.ingress.kubernetes.io/use-regex")
+	regex := rewrite || useRegex
+	switch {
+	case regex:
+		return "~* ^" + path.Path
+	case path.PathType != nil && *path.PathType == networkingv1.PathTypeExact:
+		return "= " + path.Path
+	default:
+		return path.Path
+	}
+}
+
+// writeSnippetLines copies the directives of a snippet annotation into the
+// preview, one per line at the given indent.
+func writeSnippetLines(b *strings.Builder, indent, snippet string) {
+	for _, line := range ClassifySnippet(snippet).Lines {
+		b.WriteString(indent + line.Text + "\n")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestRenderNginxPreview(t *testing.T) {
+	m := &IngressManager{}
+
+	storefront := m.BuildBasicIngress("storefront", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+	storefront.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "true"
+	storefront.Annotations["nginx.ingress.kubernetes.io/limit-rps"] = "50"
+	m.SetCustomHeaders(storefront, map[string]string{"X-Frame-Options": "DENY"})
+	storefront.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"shop.orcapod.io"}, SecretName: "shop-orcapod-tls"}}
+
+	api := m.BuildBasicIngress("storefront-api", "storefront", "api.orcapod.io", "/api/(.*)", "api-backend", 8080)
+	api.Annotations["nginx.ingress.kubernetes.io/rewrite-target"] = "/$1"
+	api.Annotations["nginx.ingress.kubernetes.io/proxy-read-timeout"] = "120"
+	api.Annotations["nginx.ingress.kubernetes.io/proxy-send-timeout"] = "120"
+	catchAll := *api.Spec.Rules[0].DeepCopy()
+	catchAll.Host = ""
+	api.Spec.Rules = append(api.Spec.Rules, catchAll)
+
+	tests := []struct {
+		name    string
+		ingress *networkingv1.Ingress
+	}{
+		{name: "storefront", ingress: storefront},
+		{name: "storefront-api", ingress: api},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderNginxPreview(tt.ingress)
+			golden := filepath.Join("testdata", "preview-"+tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("RenderNginxPreview() mismatch; rerun with -update if intended\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestRenderNginxPreviewBoolSpellings(t *testing.T) {
+	const redirect = "# plain HTTP requests are redirected"
+	tests := []struct {
+		name         string
+		annotations  map[string]string
+		tls          bool
+		wantRedirect bool
+		wantLocation string
+	}{
+		{name: "TLS defaults to redirect", tls: true, wantRedirect: true, wantLocation: "location /api/(.*) {"},
+		{
+			name:         "ssl-redirect 0",
+			annotations:  map[string]string{"nginx.ingress.kubernetes.io/ssl-redirect": "0"},
+			tls:          true,
+			wantLocation: "location /api/(.*) {",
+		},
+		{
+			name:         "force-ssl-redirect True without TLS",
+			annotations:  map[string]string{"nginx.ingress.kubernetes.io/force-ssl-redirect": "True"},
+			wantRedirect: true,
+			wantLocation: "location /api/(.*) {",
+		},
+		{
+			name:         "use-regex 1",
+			annotations:  map[string]string{"nginx.ingress.kubernetes.io/use-regex": "1"},
+			wantLocation: "location ~* ^/api/(.*) {",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &IngressManager{}
+			ingress := m.BuildBasicIngress("api", "storefront", "api.orcapod.io", "/api/(.*)", "api-backend", 8080)
+			for k, v := range tt.annotations {
+				ingress.Annotations[k] = v
+			}
+			if tt.tls {
+				ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"api.orcapod.io"}, SecretName: "api-tls"}}
+			}
+
+			got := RenderNginxPreview(ingress)
+			if strings.Contains(got, redirect) != tt.wantRedirect {
+				t.Errorf("redirect comment present = %v, want %v\n%s", !tt.wantRedirect, tt.wantRedirect, got)
+			}
+			if !strings.Contains(got, tt.wantLocation) {
+				t.Errorf("preview does not contain %q\n%s", tt.wantLocation, got)
+			}
+		})
+	}
+}
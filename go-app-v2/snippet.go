@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+)
+
+// SnippetLineKind categorizes one directive in an nginx configuration snippet.
+type SnippetLineKind string
+
+const (
+	SnippetHeaderSet   SnippetLineKind = "header-set"
+	SnippetHeaderClear SnippetLineKind = "header-clear"
+	SnippetProxyHeader SnippetLineKind = "proxy-header"
+	SnippetRewrite     SnippetLineKind = "rewrite"
+	SnippetUnknown     SnippetLineKind = "unknown"
+)
+
+// snippetDirectives maps the nginx directives ClassifySnippet recognizes to
+// their kind.
+var snippetDirectives = map[string]SnippetLineKind{
+	"more_set_headers":   SnippetHeaderSet,
+	"more_clear_headers": SnippetHeaderClear,
+	"add_header":         SnippetHeaderSet,
+	"proxy_set_header":   SnippetProxyHeader,
+	"rewrite":            SnippetRewrite,
+}
+
+// SnippetLine is one classified line of a snippet. Line is 1-based and counts
+// every line in the original snippet, including blank ones.
+type SnippetLine struct {
+	Line int
+	Text string
+	Kind SnippetLineKind
+}
+
+// SnippetClassification holds the per-line result of ClassifySnippet.
+type SnippetClassification struct {
+	Lines []SnippetLine
+}
+
+// Unknown returns the lines ClassifySnippet could not categorize. These are
+// the ones that need manual porting.
+func (c SnippetClassification) Unknown() []SnippetLine {
+	var out []SnippetLine
+	for _, l := range c.Lines {
+		if l.Kind == SnippetUnknown {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// ClassifySnippet categorizes each directive in a configuration-snippet or
+// server-snippet as header-set, header-clear, proxy-header, rewrite or
+// unknown. Blank lines and comments are skipped.
+func ClassifySnippet(snippet string) SnippetClassification {
+	var c SnippetClassification
+	for i, line := range strings.Split(snippet, "\n") {
+		text := strings.TrimSpace(line)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		kind := SnippetUnknown
+		if fields := strings.Fields(text); len(fields) > 0 {
+			if k, ok := snippetDirectives[fields[0]]; ok {
+				kind = k
+			}
+		}
+		c.Lines = append(c.Lines, SnippetLine{Line: i + 1, Text: text, Kind: kind})
+	}
+	return c
+}
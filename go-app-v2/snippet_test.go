@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifySnippet(t *testing.T) {
+	tests := []struct {
+		name        string
+		snippet     string
+		want        []SnippetLine
+		wantUnknown int
+	}{
+		{
+			name: "headers and proxy headers",
+			snippet: "more_set_headers \"X-Frame-Options: DENY\";\n" +
+				"\n" +
+				"# upstream identity\n" +
+				"proxy_set_header X-Request-ID $req_id;\n",
+			want: []SnippetLine{
+				{Line: 1, Text: `more_set_headers "X-Frame-Options: DENY";`, Kind: SnippetHeaderSet},
+				{Line: 4, Text: "proxy_set_header X-Request-ID $req_id;", Kind: SnippetProxyHeader},
+			},
+		},
+		{
+			name:    "rewrite and unknown",
+			snippet: "rewrite ^/old/(.*)$ /new/$1 break;\n  limit_req zone=api burst=5;",
+			want: []SnippetLine{
+				{Line: 1, Text: "rewrite ^/old/(.*)$ /new/$1 break;", Kind: SnippetRewrite},
+				{Line: 2, Text: "limit_req zone=api burst=5;", Kind: SnippetUnknown},
+			},
+			wantUnknown: 1,
+		},
+		{name: "empty", snippet: "\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifySnippet(tt.snippet)
+			if !reflect.DeepEqual(got.Lines, tt.want) {
+				t.Errorf("ClassifySnippet() = %+v, want %+v", got.Lines, tt.want)
+			}
+			if unknown := got.Unknown(); len(unknown) != tt.wantUnknown {
+				t.Errorf("Unknown() = %+v, want %d lines", unknown, tt.wantUnknown)
+			}
+		})
+	}
+
+	t.Run("storefront headers", func(t *testing.T) {
+		m := &IngressManager{}
+		ingress := m.BuildBasicIngress("storefront", "storefront", "shop.orcapod.io", "/", "web-frontend", 8080)
+		m.SetCustomHeaders(ingress, map[string]string{"X-Frame-Options": "DENY", "X-Content-Type-Options": "nosniff"})
+
+		got := ClassifySnippet(ingress.Annotations["nginx.ingress.kubernetes.io/configuration-snippet"])
+		if len(got.Lines) != 2 || len(got.Unknown()) != 0 {
+			t.Errorf("ClassifySnippet() = %+v, want two header-set lines", got.Lines)
+		}
+	})
+}
+
+func TestClassifySnippetHeaderClear(t *testing.T) {
+	snippet := "more_set_headers \"X-Frame-Options: DENY\";\n" +
+		"more_clear_headers \"Server\" \"X-Powered-By\";\n" +
+		"add_header X-Robots-Tag noindex;\n"
+	want := []SnippetLineKind{SnippetHeaderSet, SnippetHeaderClear, SnippetHeaderSet}
+
+	got := ClassifySnippet(snippet)
+	var kinds []SnippetLineKind
+	for _, l := range got.Lines {
+		kinds = append(kinds, l.Kind)
+	}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("kinds = %v, want %v", kinds, want)
+	}
+	if unknown := got.Unknown(); len(unknown) != 0 {
+		t.Errorf("Unknown() = %+v, want none", unknown)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Errors wrapped by RenderIngressTemplate so callers can tell which stage
+// failed with errors.Is. Validation failures are returned as *ValidationError.
+var (
+	ErrTemplateExecute = errors.New("ingress template execution failed")
+	ErrTemplateYAML    = errors.New("rendered ingress template is not valid Ingress YAML")
+)
+
+// RenderIngressTemplate executes a Go text/template that produces Ingress
+// YAML, unmarshals the result and validates it with ValidateIngress.
+func RenderIngressTemplate(tmpl string, data any) (*networkingv1.Ingress, error) {
+	t, err := template.New("ingress").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTemplateExecute, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTemplateExecute, err)
+	}
+
+	ingress := &networkingv1.Ingress{}
+	if err := yaml.UnmarshalStrict(buf.Bytes(), ingress); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTemplateYAML, err)
+	}
+	if ingress.Kind != "" && ingress.Kind != "Ingress" {
+		return nil, fmt.Errorf("%w: unexpected kind %q", ErrTemplateYAML, ingress.Kind)
+	}
+
+	if err := ValidateIngress(ingress); err != nil {
+		return nil, err
+	}
+	return ingress, nil
+}
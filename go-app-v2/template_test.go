@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+const testIngressTemplate = `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{ .Name }}
+  namespace: storefront
+spec:
+  ingressClassName: nginx
+  rules:
+  - host: {{ .Host }}
+    http:
+      paths:
+      - path: {{ .Path }}
+        pathType: Prefix
+        backend:
+          service:
+            name: {{ .Service }}
+            port:
+              number: 8080
+`
+
+func TestRenderIngressTemplate(t *testing.T) {
+	type data struct {
+		Name, Host, Path, Service string
+	}
+	tests := []struct {
+		name    string
+		tmpl    string
+		data    any
+		wantErr error
+	}{
+		{
+			name: "host and path",
+			tmpl: testIngressTemplate,
+			data: data{Name: "web", Host: "shop.orcapod.io", Path: "/", Service: "web-frontend"},
+		},
+		{
+			name:    "missing key",
+			tmpl:    testIngressTemplate,
+			data:    map[string]string{"Name": "web"},
+			wantErr: ErrTemplateExecute,
+		},
+		{
+			name:    "not yaml",
+			tmpl:    "metadata: [unterminated",
+			wantErr: ErrTemplateYAML,
+		},
+		{
+			name:    "unknown field",
+			tmpl:    "metadata:\n  name: web\nspec:\n  ingressClass: nginx\n",
+			wantErr: ErrTemplateYAML,
+		},
+		{
+			name:    "fails validation",
+			tmpl:    testIngressTemplate,
+			data:    data{Name: "", Host: "shop.orcapod.io", Path: "/", Service: "web-frontend"},
+			wantErr: ErrMissingName,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingress, err := RenderIngressTemplate(tt.tmpl, tt.data)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("RenderIngressTemplate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderIngressTemplate() error = %v", err)
+			}
+			rule := ingress.Spec.Rules[0]
+			path := rule.HTTP.Paths[0]
+			if ingress.Name != "web" || rule.Host != "shop.orcapod.io" || path.Path != "/" || path.Backend.Service.Name != "web-frontend" {
+				t.Errorf("rendered ingress = %s", IngressToString(ingress))
+			}
+		})
+	}
+}